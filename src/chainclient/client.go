@@ -0,0 +1,287 @@
+package chainclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"tendermint_proposal_monitor/config"
+)
+
+// Defaults used for any HTTP setting left empty in the configuration
+const (
+	DefaultTimeout          = 15 * time.Second
+	DefaultMaxRetries       = 3
+	DefaultBaseBackoff      = 500 * time.Millisecond
+	DefaultMaxBackoff       = 10 * time.Second
+	DefaultMaxResponseBytes = 10 << 20
+	DefaultUserAgent        = "tendermint-proposal-monitor"
+)
+
+// Client is the HTTP client shared by every chain query. It applies a per-request deadline, retries
+// transient failures with exponential backoff and jitter, and limits the size of responses.
+type Client struct {
+	HTTPClient       *http.Client
+	UserAgent        string
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	MaxResponseBytes int64
+	// ExternalHTTPClient fetches URLs taken from chain data rather than from the configuration
+	ExternalHTTPClient *http.Client
+}
+
+// StatusError is returned when an endpoint answers with a non-200 status
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request to %s failed: %s", e.URL, e.Status)
+}
+
+// ResponseTooLargeError is returned when a response is larger than the configured limit. It isn't
+// retried, since the endpoint answers the same way every time.
+type ResponseTooLargeError struct {
+	URL      string
+	MaxBytes int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response from %s exceeds %d bytes", e.URL, e.MaxBytes)
+}
+
+func New(cfg config.HTTPConfig) *Client {
+	c := &Client{
+		UserAgent:        cfg.UserAgent,
+		Timeout:          cfg.Timeout,
+		MaxRetries:       DefaultMaxRetries,
+		BaseBackoff:      DefaultBaseBackoff,
+		MaxBackoff:       DefaultMaxBackoff,
+		MaxResponseBytes: cfg.MaxResponseBytes,
+	}
+	if c.UserAgent == "" {
+		c.UserAgent = DefaultUserAgent
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if cfg.MaxRetries != nil && *cfg.MaxRetries >= 0 {
+		c.MaxRetries = *cfg.MaxRetries
+	}
+	if c.MaxResponseBytes == 0 {
+		c.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	c.HTTPClient = &http.Client{Transport: transport}
+
+	// No proxy, so the addresses checked are the ones connected to
+	external := &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, Control: refuseInternalAddress}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	c.ExternalHTTPClient = &http.Client{Transport: external}
+	return c
+}
+
+// Get fetches the URL and returns the body and status code of the final attempt. Network errors,
+// 5xx and 429 responses are retried; any other status is returned to the caller as is, and responses
+// over the size limit fail at once.
+func (c *Client) Get(ctx context.Context, url string) ([]byte, int, error) {
+	var (
+		body       []byte
+		statusCode int
+		retryAfter time.Duration
+		err        error
+	)
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			sleepErr := sleep(ctx, c.backoff(attempt, retryAfter))
+			if sleepErr != nil {
+				return nil, 0, sleepErr
+			}
+		}
+
+		body, statusCode, retryAfter, err = c.get(ctx, c.HTTPClient, url, c.MaxResponseBytes)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, 0, ctx.Err()
+			}
+			var tooLarge *ResponseTooLargeError
+			if errors.As(err, &tooLarge) {
+				return nil, 0, err
+			}
+			continue
+		}
+		if !isRetryableStatus(statusCode) {
+			return body, statusCode, nil
+		}
+	}
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching %s after %d attempts: %w", url, c.MaxRetries+1, err)
+	}
+	return body, statusCode, nil
+}
+
+// GetJSON fetches the URL and decodes a 200 response into out
+func (c *Client) GetJSON(ctx context.Context, url string, out interface{}) error {
+	body, statusCode, err := c.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusOK {
+		return &StatusError{URL: url, StatusCode: statusCode, Status: fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)), Body: string(body)}
+	}
+
+	err = json.Unmarshal(body, out)
+	if err != nil {
+		return fmt.Errorf("error decoding response from %s: %v", url, err)
+	}
+	return nil
+}
+
+// GetExternal fetches a URL taken from chain data, such as the plan info of an upgrade proposal,
+// which whoever submitted the proposal chose. Hosts resolving to private, loopback or link-local
+// addresses are refused, at most maxBytes are read, and failures aren't retried.
+func (c *Client) GetExternal(ctx context.Context, url string, maxBytes int64) ([]byte, int, error) {
+	body, statusCode, _, err := c.get(ctx, c.ExternalHTTPClient, url, maxBytes)
+	return body, statusCode, err
+}
+
+func (c *Client) get(ctx context.Context, httpClient *http.Client, url string, maxBytes int64) ([]byte, int, time.Duration, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, 0, 0, &ResponseTooLargeError{URL: url, MaxBytes: maxBytes}
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return body, resp.StatusCode, retryAfter, nil
+}
+
+// internalPrefixes are the address ranges external fetches refuse: private, shared, loopback, link-local,
+// reserved and multicast ranges, and the IPv6 ranges that translate or tunnel to an IPv4 address,
+// which could be an internal one
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/96"),
+	netip.MustParsePrefix("::ffff:0:0/96"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// refuseInternalAddress keeps external fetches from reaching the monitor's own network, checking the
+// address actually dialed so a host can't resolve to a public address first and an internal one later
+func refuseInternalAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	addr := addrPort.Addr().WithZone("")
+	for _, prefix := range internalPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("refusing to connect to internal address %s", addr)
+		}
+	}
+	return nil
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before the given attempt using exponential backoff with full jitter,
+// unless the endpoint asked to wait for a specific time
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if retryAfter > c.MaxBackoff {
+			return c.MaxBackoff
+		}
+		return retryAfter
+	}
+
+	backoff := c.BaseBackoff << (attempt - 1)
+	if backoff > c.MaxBackoff || backoff <= 0 {
+		backoff = c.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package chainclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tendermint_proposal_monitor/config"
+)
+
+func TestGetRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		requests int
+		tooLarge bool
+	}{
+		{"ok", "{}", http.StatusOK, 1, false},
+		{"not found", "{}", http.StatusNotFound, 1, false},
+		{"server error", "{}", http.StatusInternalServerError, 3, false},
+		{"response too large", strings.Repeat("x", 64), http.StatusOK, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			maxRetries := 2
+			client := New(config.HTTPConfig{MaxRetries: &maxRetries, MaxResponseBytes: 32})
+			client.BaseBackoff = time.Millisecond
+			client.MaxBackoff = time.Millisecond
+
+			_, statusCode, err := client.Get(context.Background(), server.URL)
+			var tooLarge *ResponseTooLargeError
+			if errors.As(err, &tooLarge) != test.tooLarge {
+				t.Errorf("Get error = %v, want response too large: %v", err, test.tooLarge)
+			}
+			if !test.tooLarge && statusCode != test.status {
+				t.Errorf("Get status = %d, want %d", statusCode, test.status)
+			}
+			if requests != test.requests {
+				t.Errorf("requests = %d, want %d", requests, test.requests)
+			}
+		})
+	}
+}
+
+func TestRefuseInternalAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		refused bool
+	}{
+		{"8.8.8.8:443", false},
+		{"[2606:4700:4700::1111]:443", false},
+		{"10.1.2.3:443", true},
+		{"172.16.0.1:443", true},
+		{"192.168.1.1:443", true},
+		{"127.0.0.1:443", true},
+		{"0.0.0.0:443", true},
+		{"169.254.169.254:80", true},
+		{"100.64.0.1:443", true},
+		{"100.127.255.254:443", true},
+		{"100.128.0.1:443", false},
+		{"198.18.0.1:443", true},
+		{"224.0.0.1:443", true},
+		{"255.255.255.255:443", true},
+		{"[::1]:443", true},
+		{"[::]:443", true},
+		{"[::ffff:127.0.0.1]:443", true},
+		{"[::ffff:8.8.8.8]:443", true},
+		{"[64:ff9b::a00:1]:443", true},
+		{"[64:ff9b:1::1]:443", true},
+		{"[2001:0:4136:e378::1]:443", true},
+		{"[2002:a00:1::1]:443", true},
+		{"[fd00::1]:443", true},
+		{"[fe80::1%eth0]:443", true},
+		{"[ff02::1]:443", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.address, func(t *testing.T) {
+			err := refuseInternalAddress("tcp", test.address, nil)
+			if (err != nil) != test.refused {
+				t.Errorf("refuseInternalAddress(%q) = %v, want refused: %v", test.address, err, test.refused)
+			}
+		})
+	}
+}
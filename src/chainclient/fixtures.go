@@ -0,0 +1,176 @@
+package chainclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tendermint_proposal_monitor/config"
+)
+
+// FixtureTransport answers chain queries from fixture files instead of the network, for mock runs.
+// The response to a request is read from <dir>/<chain name>/<request path>.json, so fixtures are
+// plain LCD responses. Requests with a query string are answered from <request path>@<query hash>.json
+// first, as recorded, falling back to the fixture of the path. Requests without a fixture get a 404, which is what
+// the LCD answers for a missing vote. Placeholders such as {{now}}, {{now+6h}} or {{now-30m}} are
+// replaced with RFC3339 times relative to the request, so scenarios like an approaching voting deadline
+// can be kept as fixtures. A <request path>.status file next to a fixture sets another status code.
+type FixtureTransport struct {
+	Dir       string
+	endpoints fixtureEndpoints
+}
+
+type fixtureEndpoint struct {
+	URL       string
+	ChainName string
+}
+
+// fixtureEndpoints maps every configured endpoint to its chain name, longest endpoint first. Endpoints
+// are kept without user information or query string, which aren't part of the URL requests are
+// matched by.
+type fixtureEndpoints []fixtureEndpoint
+
+var nowPlaceholder = regexp.MustCompile(`\{\{now([+-][0-9a-z.]+)?\}\}`)
+
+func newFixtureEndpoints(chains map[string]config.ChainConfig) fixtureEndpoints {
+	var endpoints fixtureEndpoints
+	for chainName, chain := range chains {
+		for _, endpoint := range chainEndpoints(chain) {
+			if parsed, err := url.Parse(endpoint); err == nil {
+				parsed.User = nil
+				parsed.RawQuery = ""
+				endpoint = parsed.String()
+			}
+			endpoints = append(endpoints, fixtureEndpoint{URL: strings.TrimSuffix(endpoint, "/"), ChainName: chainName})
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return len(endpoints[i].URL) > len(endpoints[j].URL)
+	})
+	return endpoints
+}
+
+// chainEndpoints returns every endpoint configured for the chain
+func chainEndpoints(chain config.ChainConfig) []string {
+	var endpoints []string
+	for _, endpoint := range append([]string{chain.APIEndpoint, chain.OwnNodeEndpoint}, chain.FallbackEndpoints...) {
+		if endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// fixturePath returns the fixture file of a request, without extension
+func (e fixtureEndpoints) fixturePath(dir string, req *http.Request) (string, error) {
+	requestURL := fmt.Sprintf("%s://%s%s", req.URL.Scheme, req.URL.Host, req.URL.EscapedPath())
+	for _, endpoint := range e {
+		if strings.HasPrefix(requestURL, endpoint.URL) {
+			return filepath.Join(dir, endpoint.ChainName, filepath.FromSlash(strings.TrimPrefix(requestURL, endpoint.URL))), nil
+		}
+	}
+	return "", fmt.Errorf("no fixtures for %s, which isn't an endpoint of a configured chain", requestURL)
+}
+
+// queryFixturePath returns the fixture file of a request with its query string, without extension: the
+// path followed by a hash of the query parameters sorted by name, so the name doesn't depend on their
+// order. It is the path itself for requests without a query string.
+func queryFixturePath(path string, req *http.Request) string {
+	query := req.URL.Query()
+	if len(query) == 0 {
+		return path
+	}
+	hash := sha256.Sum256([]byte(query.Encode()))
+	return path + "@" + hex.EncodeToString(hash[:6])
+}
+
+func NewFixtureTransport(dir string, chains map[string]config.ChainConfig) *FixtureTransport {
+	return &FixtureTransport{Dir: dir, endpoints: newFixtureEndpoints(chains)}
+}
+
+func (t *FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path, err := t.endpoints.fixturePath(t.Dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if queryPath := queryFixturePath(path, req); queryPath != path {
+		if _, err := os.Stat(queryPath + ".json"); err == nil {
+			path = queryPath
+		}
+	}
+
+	body, err := os.ReadFile(path + ".json")
+	if os.IsNotExist(err) {
+		return fixtureResponse(req, http.StatusNotFound, []byte(`{"code":5,"message":"no fixture"}`)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statusCode := http.StatusOK
+	status, err := os.ReadFile(path + ".status")
+	if err == nil {
+		statusCode, err = strconv.Atoi(strings.TrimSpace(string(status)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixture status %s.status: %v", path, err)
+		}
+	}
+
+	body, err = expandTimes(body, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixture %s.json: %v", path, err)
+	}
+	return fixtureResponse(req, statusCode, body), nil
+}
+
+func expandTimes(body []byte, now time.Time) ([]byte, error) {
+	var err error
+	expanded := nowPlaceholder.ReplaceAllFunc(body, func(match []byte) []byte {
+		offset := nowPlaceholder.FindSubmatch(match)[1]
+		t := now
+		if len(offset) > 0 {
+			d, parseErr := time.ParseDuration(string(offset))
+			if parseErr != nil {
+				err = parseErr
+				return match
+			}
+			t = now.Add(d)
+		}
+		return []byte(t.UTC().Format(time.RFC3339Nano))
+	})
+	return expanded, err
+}
+
+func fixtureResponse(req *http.Request, statusCode int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// NewFixtureClient returns a client answering every query from the fixtures in dir
+func NewFixtureClient(cfg config.HTTPConfig, dir string, chains map[string]config.ChainConfig) *Client {
+	c := New(cfg)
+	c.HTTPClient = &http.Client{Transport: NewFixtureTransport(dir, chains)}
+	c.ExternalHTTPClient = c.HTTPClient
+	return c
+}
@@ -0,0 +1,125 @@
+package chainclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Block is the header information of a block needed to judge how current a node is
+type Block struct {
+	Height int64
+	Time   time.Time
+}
+
+type blockResponse struct {
+	Block struct {
+		Header struct {
+			Height string    `json:"height"`
+			Time   time.Time `json:"time"`
+		} `json:"header"`
+	} `json:"block"`
+	// SDKBlock replaces Block in SDK 0.47 and later, which still serve both
+	SDKBlock *struct {
+		Header struct {
+			Height string    `json:"height"`
+			Time   time.Time `json:"time"`
+		} `json:"header"`
+	} `json:"sdk_block"`
+}
+
+func (r blockResponse) toBlock() (*Block, error) {
+	header := r.Block.Header
+	if r.SDKBlock != nil && r.SDKBlock.Header.Height != "" {
+		header = r.SDKBlock.Header
+	}
+	height, err := strconv.ParseInt(header.Height, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block height %q: %v", header.Height, err)
+	}
+	return &Block{Height: height, Time: header.Time}, nil
+}
+
+// LatestBlock returns the latest block known to the node behind the endpoint
+func (c *Client) LatestBlock(ctx context.Context, endpoint string) (*Block, error) {
+	var resp blockResponse
+	err := c.GetJSON(ctx, fmt.Sprintf("%s/cosmos/base/tendermint/v1beta1/blocks/latest", endpoint), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.toBlock()
+}
+
+// Syncing reports whether the node behind the endpoint is still catching up with the chain
+func (c *Client) Syncing(ctx context.Context, endpoint string) (bool, error) {
+	var resp struct {
+		Syncing bool `json:"syncing"`
+	}
+	err := c.GetJSON(ctx, fmt.Sprintf("%s/cosmos/base/tendermint/v1beta1/syncing", endpoint), &resp)
+	if err != nil {
+		return false, err
+	}
+	return resp.Syncing, nil
+}
+
+// NodeInfo describes the network and software of the node behind an endpoint
+type NodeInfo struct {
+	DefaultNodeInfo struct {
+		Network string `json:"network"`
+		Version string `json:"version"`
+		Moniker string `json:"moniker"`
+	} `json:"default_node_info"`
+	ApplicationVersion struct {
+		Name             string `json:"name"`
+		AppName          string `json:"app_name"`
+		Version          string `json:"version"`
+		GitCommit        string `json:"git_commit"`
+		CosmosSDKVersion string `json:"cosmos_sdk_version"`
+	} `json:"application_version"`
+}
+
+func (c *Client) NodeInfo(ctx context.Context, endpoint string) (*NodeInfo, error) {
+	var resp NodeInfo
+	err := c.GetJSON(ctx, fmt.Sprintf("%s/cosmos/base/tendermint/v1beta1/node_info", endpoint), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// BlockAt returns the block at the given height
+func (c *Client) BlockAt(ctx context.Context, endpoint string, height int64) (*Block, error) {
+	var resp blockResponse
+	err := c.GetJSON(ctx, fmt.Sprintf("%s/cosmos/base/tendermint/v1beta1/blocks/%d", endpoint, height), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.toBlock()
+}
+
+// AverageBlockTime returns the latest block along with the average time between the blocks of the
+// last window blocks
+func (c *Client) AverageBlockTime(ctx context.Context, endpoint string, window int64) (*Block, time.Duration, error) {
+	latest, err := c.LatestBlock(ctx, endpoint)
+	if err != nil {
+		return nil, 0, err
+	}
+	if window >= latest.Height {
+		window = latest.Height - 1
+	}
+	if window <= 0 {
+		return nil, 0, fmt.Errorf("not enough blocks to measure block time")
+	}
+
+	earlier, err := c.BlockAt(ctx, endpoint, latest.Height-window)
+	if err != nil {
+		return nil, 0, err
+	}
+	return latest, latest.Time.Sub(earlier.Time) / time.Duration(window), nil
+}
+
+// EstimateTimeAt estimates when the chain reaches, or reached, the given height
+func EstimateTimeAt(latest *Block, averageBlockTime time.Duration, height int64) time.Time {
+	return latest.Time.Add(time.Duration(height-latest.Height) * averageBlockTime)
+}
@@ -0,0 +1,173 @@
+package chainclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"tendermint_proposal_monitor/config"
+)
+
+// RecordingManifest is the file describing a recording
+const RecordingManifest = "recording.json"
+
+// Redacted replaces secrets in recorded responses
+const Redacted = "REDACTED"
+
+// Recording describes when the responses in a recording directory were captured, so that a replay
+// can judge them as of that time
+type Recording struct {
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// RecordingTransport passes chain queries on to the network and writes every response to a
+// directory in the fixture layout read by FixtureTransport, so a recording can be replayed as
+// fixtures. Request headers and query strings are never written, and credentials found in the
+// configured endpoints, along with any extra secret, are scrubbed from the response bodies.
+type RecordingTransport struct {
+	Next      http.RoundTripper
+	Dir       string
+	endpoints fixtureEndpoints
+	secrets   []string
+	mu        sync.Mutex
+}
+
+func NewRecordingTransport(next http.RoundTripper, dir string, chains map[string]config.ChainConfig, extraSecrets []string) *RecordingTransport {
+	t := &RecordingTransport{Next: next, Dir: dir, endpoints: newFixtureEndpoints(chains)}
+	for _, chain := range chains {
+		for _, endpoint := range chainEndpoints(chain) {
+			t.secrets = append(t.secrets, endpointSecrets(endpoint)...)
+		}
+	}
+	for _, secret := range extraSecrets {
+		if secret != "" {
+			t.secrets = append(t.secrets, secret)
+		}
+	}
+	return t
+}
+
+// minSecretLength keeps short query values and path segments, which are unlikely to be keys, from
+// being scrubbed out of every response that happens to contain them
+const minSecretLength = 16
+
+// endpointSecrets returns the parts of an endpoint URL that commonly carry API keys: the user
+// information, and long query values and path segments
+func endpointSecrets(endpoint string) []string {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return nil
+	}
+
+	var secrets []string
+	if parsed.User != nil {
+		secrets = append(secrets, parsed.User.String())
+	}
+	candidates := strings.Split(parsed.Path, "/")
+	for _, values := range parsed.Query() {
+		candidates = append(candidates, values...)
+	}
+	for _, candidate := range candidates {
+		if len(candidate) >= minSecretLength {
+			secrets = append(secrets, candidate)
+		}
+	}
+	return secrets
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Responses the client would retry aren't the answer the monitor acted on
+	if isRetryableStatus(resp.StatusCode) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = t.record(req, resp.StatusCode, body)
+	if err != nil {
+		log.Print(t.scrub(fmt.Sprintf("Error recording response to %s: %v", req.URL.Redacted(), err)))
+	}
+	return resp, nil
+}
+
+func (t *RecordingTransport) record(req *http.Request, statusCode int, body []byte) error {
+	path, err := t.endpoints.fixturePath(t.Dir, req)
+	if err != nil {
+		return err
+	}
+	path = queryFixturePath(path, req)
+	// Replays look responses up by the path of the request, which a scrubbed path wouldn't match
+	if relative := strings.TrimPrefix(path, t.Dir); t.scrub(relative) != relative {
+		return fmt.Errorf("request path contains a secret")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path+".json", []byte(t.scrub(string(body))), 0o644)
+	if err != nil {
+		return err
+	}
+	if statusCode == http.StatusOK {
+		err = os.Remove(path + ".status")
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		err = os.WriteFile(path+".status", []byte(fmt.Sprintf("%d\n", statusCode)), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest, err := json.MarshalIndent(Recording{RecordedAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(t.Dir, RecordingManifest), manifest, 0o644)
+}
+
+func (t *RecordingTransport) scrub(content string) string {
+	for _, secret := range t.secrets {
+		content = strings.ReplaceAll(content, secret, Redacted)
+	}
+	return content
+}
+
+// LoadRecording reads the manifest of a recording directory
+func LoadRecording(dir string) (*Recording, error) {
+	data, err := os.ReadFile(filepath.Join(dir, RecordingManifest))
+	if err != nil {
+		return nil, err
+	}
+
+	var recording Recording
+	err = json.Unmarshal(data, &recording)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording manifest: %v", err)
+	}
+	return &recording, nil
+}
@@ -0,0 +1,165 @@
+package chainclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tendermint_proposal_monitor/config"
+)
+
+const (
+	testPathKey  = "gatewaykey0123456789abcdef"
+	testPassword = "password0123456789"
+	testQueryKey = "querykey0123456789abcdef"
+	testScrubbed = "cosmos1scrubbedvoter"
+)
+
+func TestRecordingReplay(t *testing.T) {
+	var secretsEchoed string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/"+testPathKey) {
+		case "/cosmos/gov/v1/proposals/5":
+			w.Write([]byte(`{"proposal":{"id":"5","note":"` + secretsEchoed + `"}}`))
+		case "/cosmos/gov/v1/proposals":
+			w.Write([]byte(`{"proposals":[],"query":"` + r.URL.RawQuery + `"}`))
+		case "/cosmos/gov/v1/proposals/5/votes/" + testScrubbed:
+			w.Write([]byte(`{"vote":{"voter":"` + testScrubbed + `"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":5,"message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	endpoint := strings.Replace(server.URL, "http://", "http://user:"+testPassword+"@", 1) + "/" + testPathKey
+	// The fallback is never queried, but its key is scrubbed like those of the endpoints in use
+	fallback := server.URL + "/lcd?api_key=" + testQueryKey
+	secretsEchoed = endpoint + " " + fallback
+	chains := map[string]config.ChainConfig{
+		"cosmoshub": {APIEndpoint: endpoint, FallbackEndpoints: []string{fallback}},
+	}
+
+	requests := []struct {
+		name     string
+		url      string
+		status   int
+		recorded string
+	}{
+		{"proposal", endpoint + "/cosmos/gov/v1/proposals/5", http.StatusOK, "cosmoshub/cosmos/gov/v1/proposals/5.json"},
+		{"missing vote", endpoint + "/cosmos/gov/v1/proposals/5/votes/cosmos1voter", http.StatusNotFound, "cosmoshub/cosmos/gov/v1/proposals/5/votes/cosmos1voter.status"},
+		{"query", endpoint + "/cosmos/gov/v1/proposals?proposal_status=2&pagination.key=abc", http.StatusOK, ""},
+		{"secret in path", endpoint + "/cosmos/gov/v1/proposals/5/votes/" + testScrubbed, http.StatusOK, ""},
+	}
+
+	dir := t.TempDir()
+	recorder := &http.Client{Transport: NewRecordingTransport(http.DefaultTransport, dir, chains, []string{testScrubbed})}
+	recorded := make(map[string]string)
+	for _, request := range requests {
+		resp, err := recorder.Get(request.url)
+		if err != nil {
+			t.Fatalf("recording %s: %v", request.name, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != request.status {
+			t.Errorf("recording %s: status = %d, want %d", request.name, resp.StatusCode, request.status)
+		}
+		recorded[request.name] = string(body)
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, secret := range []string{testPathKey, testPassword, testQueryKey, testScrubbed} {
+			if strings.Contains(path, secret) || strings.Contains(string(content), secret) {
+				t.Errorf("recorded %s contains %s", path, secret)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("reading recording: %v", err)
+	}
+	for _, request := range requests {
+		if request.recorded == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(request.recorded))); err != nil {
+			t.Errorf("recording %s: %v", request.name, err)
+		}
+	}
+	if _, err := LoadRecording(dir); err != nil {
+		t.Errorf("LoadRecording() error = %v", err)
+	}
+
+	replays := []struct {
+		name   string
+		url    string
+		status int
+		body   string
+	}{
+		{"proposal", endpoint + "/cosmos/gov/v1/proposals/5", http.StatusOK, `{"proposal":{"id":"5","note":"http://REDACTED@` + strings.TrimPrefix(server.URL, "http://") + `/REDACTED ` + server.URL + `/lcd?api_key=REDACTED"}}`},
+		{"missing vote", endpoint + "/cosmos/gov/v1/proposals/5/votes/cosmos1voter", http.StatusNotFound, recorded["missing vote"]},
+		{"query", endpoint + "/cosmos/gov/v1/proposals?proposal_status=2&pagination.key=abc", http.StatusOK, recorded["query"]},
+		{"query in other order", endpoint + "/cosmos/gov/v1/proposals?pagination.key=abc&proposal_status=2", http.StatusOK, recorded["query"]},
+		{"other query", endpoint + "/cosmos/gov/v1/proposals?proposal_status=3", http.StatusNotFound, `{"code":5,"message":"no fixture"}`},
+		{"secret in path", endpoint + "/cosmos/gov/v1/proposals/5/votes/" + testScrubbed, http.StatusNotFound, `{"code":5,"message":"no fixture"}`},
+	}
+
+	replayer := &http.Client{Transport: NewFixtureTransport(dir, chains)}
+	for _, replay := range replays {
+		t.Run(replay.name, func(t *testing.T) {
+			resp, err := replayer.Get(replay.url)
+			if err != nil {
+				t.Fatalf("replay error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != replay.status || string(body) != replay.body {
+				t.Errorf("replay = %d %s, want %d %s", resp.StatusCode, body, replay.status, replay.body)
+			}
+		})
+	}
+}
+
+func TestExpandTimes(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+		wantErr  bool
+	}{
+		{"now", `{"time":"{{now}}"}`, `{"time":"2024-05-01T12:00:00Z"}`, false},
+		{"later", `{"time":"{{now+6h}}"}`, `{"time":"2024-05-01T18:00:00Z"}`, false},
+		{"earlier", `{"time":"{{now-30m}}"}`, `{"time":"2024-05-01T11:30:00Z"}`, false},
+		{"fraction", `{"time":"{{now+1.5h}}"}`, `{"time":"2024-05-01T13:30:00Z"}`, false},
+		{"no placeholder", `{"time":"2024-05-01T12:00:00Z"}`, `{"time":"2024-05-01T12:00:00Z"}`, false},
+		{"unknown unit", `{"time":"{{now+6d}}"}`, `{"time":"{{now+6d}}"}`, true},
+		{"missing unit", `{"time":"{{now+6}}"}`, `{"time":"{{now+6}}"}`, true},
+		{"bad duration among good ones", `["{{now}}","{{now+x}}"]`, `["2024-05-01T12:00:00Z","{{now+x}}"]`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expanded, err := expandTimes([]byte(test.body), now)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expandTimes(%s) error = %v, wantErr %v", test.body, err, test.wantErr)
+			}
+			if string(expanded) != test.expected {
+				t.Errorf("expandTimes(%s) = %s, want %s", test.body, expanded, test.expected)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"io/ioutil"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -12,20 +13,85 @@ type Configurations struct {
 	Discord                    DiscordConfig          `yaml:"discord"`
 	Chains                     map[string]ChainConfig `yaml:"chains"`
 	Storage                    Storage                `yaml:"storage"`
+	HTTP                       HTTPConfig             `yaml:"http"`
+	MaxBlockLag                time.Duration          `yaml:"max_block_lag"`
+	DescriptionLength          int                    `yaml:"description_length"`
+	MockFixturesDir            string                 `yaml:"mock_fixtures_dir"`
+	RecordScrub                []string               `yaml:"record_scrub"`
+	MonitorURL                 string                 `yaml:"monitor_url"`
+}
+
+// HTTPConfig tunes the client used for every chain query. Empty values fall back to defaults.
+// MaxRetries is a pointer so that 0, which disables retries, isn't taken for empty.
+type HTTPConfig struct {
+	Timeout          time.Duration `yaml:"timeout"`
+	MaxRetries       *int          `yaml:"max_retries"`
+	UserAgent        string        `yaml:"user_agent"`
+	MaxResponseBytes int64         `yaml:"max_response_bytes"`
 }
 
 type DiscordConfig struct {
 	Enabled bool   `yaml:"enabled"`
 	Webhook string `yaml:"webhook"`
+	Mention string `yaml:"mention"`
 }
 
 type ChainConfig struct {
-	ChainID          string      `yaml:"chain_id"`
-	ValidatorAddress string      `yaml:"validator_address"`
-	APIVersion       string      `yaml:"api_version"`
-	APIEndpoint      string      `yaml:"api_endpoint"`
-	ExplorerURL      string      `yaml:"explorer_url"`
-	Alerts           AlertConfig `yaml:"alerts"`
+	ChainID           string            `yaml:"chain_id"`
+	ValidatorAddress  string            `yaml:"validator_address"`
+	APIVersion        string            `yaml:"api_version"`
+	APIEndpoint       string            `yaml:"api_endpoint"`
+	FallbackEndpoints []string          `yaml:"fallback_endpoints"`
+	ExplorerURL       string            `yaml:"explorer_url"`
+	Alerts            AlertConfig       `yaml:"alerts"`
+	Groups            []GroupConfig     `yaml:"groups"`
+	DAOs              []DAOConfig       `yaml:"daos"`
+	OwnNodeEndpoint   string            `yaml:"own_node_endpoint"`
+	UpgradeVersions   map[string]string `yaml:"upgrade_versions"`
+	ProposalSource    string            `yaml:"proposal_source"`
+	AccountPrefix     string            `yaml:"account_prefix"`
+	Voters            []VoterConfig     `yaml:"voters"`
+	PeerValidators    int               `yaml:"peer_validators"`
+	Binary            string            `yaml:"binary"`
+}
+
+// VoterConfig is one of our addresses whose votes are checked, labelled for alerts
+type VoterConfig struct {
+	Label   string `yaml:"label"`
+	Address string `yaml:"address"`
+	// Grantee signs prepared votes of the address through authz, if set
+	Grantee string `yaml:"grantee"`
+}
+
+// DefaultVoterLabel labels validator_address when no voters are configured
+const DefaultVoterLabel = "Validator"
+
+// VoterList returns the voters of the chain, or validator_address alone when there are none
+func (c ChainConfig) VoterList() []VoterConfig {
+	if len(c.Voters) > 0 {
+		return c.Voters
+	}
+	if c.ValidatorAddress == "" {
+		return nil
+	}
+	return []VoterConfig{{Label: DefaultVoterLabel, Address: c.ValidatorAddress}}
+}
+
+// GroupConfig selects x/group proposals to monitor, either of a single group policy or of every
+// policy of a group, and the member address whose votes are checked
+type GroupConfig struct {
+	GroupID       string `yaml:"group_id"`
+	PolicyAddress string `yaml:"policy_address"`
+	MemberAddress string `yaml:"member_address"`
+}
+
+// DAOConfig selects a DAO DAO proposal module contract (dao-proposal-single or dao-proposal-multiple)
+// to monitor, and the member address whose votes are checked
+type DAOConfig struct {
+	Name            string `yaml:"name"`
+	ContractAddress string `yaml:"contract_address"`
+	VoterAddress    string `yaml:"voter_address"`
+	DetailURL       string `yaml:"detail_url"`
 }
 
 type AlertConfig struct {
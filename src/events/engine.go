@@ -0,0 +1,304 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"tendermint_proposal_monitor/proposals"
+)
+
+// StageExpedited marks the deadline reminder of a proposal while it is expedited, so a new
+// reminder is due once it is converted to a regular proposal
+const StageExpedited = "expedited"
+
+// NearingFraction is the share of the voting period before its end at which the deadline reminder
+// is sent when that is sooner than the nearing window, for chains with short voting periods and for
+// expedited proposals
+const NearingFraction = 0.25
+
+// UpgradeStage is a countdown alert sent when an upgrade is estimated to be less than Before away
+type UpgradeStage struct {
+	Stage  string
+	Before time.Duration
+}
+
+// UpgradeStages are ordered from the earliest to the latest countdown alert
+var UpgradeStages = []UpgradeStage{
+	{Stage: "T-24h", Before: 24 * time.Hour},
+	{Stage: "T-1h", Before: time.Hour},
+	{Stage: "T-10min", Before: 10 * time.Minute},
+}
+
+// Engine diffs the last known proposal snapshot of a chain against a fresh fetch
+type Engine struct {
+	NearingWindow time.Duration
+}
+
+func NewEngine(nearingWindow time.Duration) *Engine {
+	return &Engine{NearingWindow: nearingWindow}
+}
+
+// ChainState is everything the engine needs to know about a chain to diff a fetch against it
+type ChainState struct {
+	ChainName   string
+	Previous    map[string]proposals.ProposalSnapshot
+	LastChecked int
+	// Voters are the addresses whose votes are tracked
+	Voters []string
+	// Votes holds the vote of every voter, by proposal, where it could be checked during this run. The
+	// vote is nil for voters that haven't voted.
+	Votes map[string]map[string]*proposals.Vote
+	// DetectCancellations is set for sources that delete cancelled proposals but keep closed ones
+	DetectCancellations bool
+	// Params are the gov params of the chain, nil when they are unknown or don't apply to the source
+	Params *proposals.GovParams
+}
+
+// Snapshot builds the snapshot to persist after the given fetch, keeping the last known votes of
+// voters whose vote could not be checked this run
+func (e *Engine) Snapshot(state ChainState, current []proposals.Proposal) map[string]proposals.ProposalSnapshot {
+	snapshots := make(map[string]proposals.ProposalSnapshot)
+	for _, proposal := range current {
+		snapshots[proposal.ProposalID] = snapshotOf(state, proposal)
+	}
+	return snapshots
+}
+
+// Diff returns the events implied by the fresh fetch, in the order they should be published
+func (e *Engine) Diff(state ChainState, current []proposals.Proposal, now time.Time) []Event {
+	var events []Event
+	newEvent := func(eventType Type, proposal proposals.Proposal) Event {
+		votes := voteStatus(state, proposal)
+		event := Event{
+			Type:       eventType,
+			ChainName:  state.ChainName,
+			Proposal:   proposal,
+			Snapshot:   snapshotOf(state, proposal),
+			Votes:      votes,
+			VoteKnown:  len(state.Voters) > 0 && len(votes) == len(state.Voters),
+			OccurredAt: now,
+		}
+		if previous, known := state.Previous[proposal.ProposalID]; known {
+			event.Previous = &previous
+		}
+		return event
+	}
+
+	lowestID := -1
+	for _, proposal := range current {
+		proposalID, err := strconv.Atoi(proposal.ProposalID)
+		if err != nil {
+			continue
+		}
+		if lowestID == -1 || proposalID < lowestID {
+			lowestID = proposalID
+		}
+
+		previous, known := state.Previous[proposal.ProposalID]
+		final := proposals.IsFinalStatus(proposal.Status)
+
+		if proposalID > state.LastChecked && !final {
+			events = append(events, newEvent(ProposalSubmitted, proposal))
+		}
+
+		if known && previous.Status == proposals.ProposalStatusDepositPeriod && proposal.Status == proposals.ProposalStatusVotingPeriod {
+			events = append(events, newEvent(VotingStarted, proposal))
+		}
+
+		if proposal.Status == proposals.ProposalStatusVotingPeriod {
+			for _, voter := range state.Voters {
+				vote := state.Votes[proposal.ProposalID][voter]
+				if vote != nil && !(known && previous.HasVoted(voter)) {
+					event := newEvent(VoteDetected, proposal)
+					event.Voter = voter
+					event.Vote = vote
+					event.Stage = voter
+					events = append(events, event)
+				} else if voteChanged(state, proposal, voter) {
+					// A vote may change more than once, even back to an earlier vote, so each change is its
+					// own stage, numbered by the changes of the voter's vote seen before
+					event := newEvent(VoteChanged, proposal)
+					event.Voter = voter
+					event.Vote = vote
+					event.Stage = fmt.Sprintf("%s/%d", voter, previous.VoteChanges[voter]+1)
+					events = append(events, event)
+				}
+			}
+
+			if known && previous.Expedited && !proposal.Expedited {
+				events = append(events, newEvent(ExpeditedConverted, proposal))
+			}
+
+			votingEndTime, err := time.Parse(time.RFC3339, proposal.VotingEndTime)
+			if err == nil && votingEndTime.Sub(now) <= e.nearingWindow(state.Params, proposal, votingEndTime) {
+				event := newEvent(DeadlineApproaching, proposal)
+				if proposal.Expedited {
+					event.Stage = StageExpedited
+				}
+				events = append(events, event)
+			}
+		}
+
+		if stage, ok := upgradeStage(proposal, now); ok {
+			event := newEvent(UpgradeApproaching, proposal)
+			event.Stage = stage
+			events = append(events, event)
+		}
+
+		if known && !proposals.IsFinalStatus(previous.Status) && final {
+			events = append(events, newEvent(ProposalClosed, proposal))
+		}
+	}
+
+	// Cancelled proposals are deleted from the chain. Only proposals that were in their voting
+	// period are considered, since deposit period proposals are also deleted when their deposit
+	// expires, and only within the fetched ID range so pagination isn't mistaken for a cancellation.
+	if !state.DetectCancellations || len(current) == 0 {
+		return events
+	}
+	fetched := make(map[string]bool)
+	for _, proposal := range current {
+		fetched[proposal.ProposalID] = true
+	}
+	for proposalID, previous := range state.Previous {
+		if fetched[proposalID] || previous.Status != proposals.ProposalStatusVotingPeriod {
+			continue
+		}
+		id, err := strconv.Atoi(proposalID)
+		if err != nil || id < lowestID {
+			continue
+		}
+		previous := previous
+		events = append(events, Event{
+			Type:      ProposalCancelled,
+			ChainName: state.ChainName,
+			Proposal: proposals.Proposal{
+				ProposalID:    previous.ProposalID,
+				Status:        previous.Status,
+				VotingEndTime: previous.VotingEndTime,
+			},
+			Snapshot:   previous,
+			Previous:   &previous,
+			OccurredAt: now,
+		})
+	}
+
+	return events
+}
+
+// nearingWindow scales the reminder window down to the voting period of the proposal, taken from the
+// gov params of the chain when known. Without params, only expedited proposals are scaled, using the
+// voting period of the proposal itself.
+func (e *Engine) nearingWindow(params *proposals.GovParams, proposal proposals.Proposal, votingEndTime time.Time) time.Duration {
+	var votingPeriod time.Duration
+	switch {
+	case params != nil && proposal.Expedited && params.ExpeditedVotingPeriod > 0:
+		votingPeriod = params.ExpeditedVotingPeriod
+	case params != nil && !proposal.Expedited && params.VotingPeriod > 0:
+		votingPeriod = params.VotingPeriod
+	case proposal.Expedited:
+		votingStartTime, err := time.Parse(time.RFC3339, proposal.VotingStartTime)
+		if err != nil {
+			return e.NearingWindow
+		}
+		votingPeriod = votingEndTime.Sub(votingStartTime)
+	default:
+		return e.NearingWindow
+	}
+
+	window := time.Duration(float64(votingPeriod) * NearingFraction)
+	if window > e.NearingWindow {
+		return e.NearingWindow
+	}
+	return window
+}
+
+// upgradeStage returns the latest countdown stage reached by the estimated upgrade time of the
+// proposal. Earlier stages that were never alerted on are skipped rather than sent late.
+func upgradeStage(proposal proposals.Proposal, now time.Time) (string, bool) {
+	if !proposals.HasPendingUpgrade(proposal) || proposal.Upgrade.EstimatedTime == "" {
+		return "", false
+	}
+	estimatedTime, err := time.Parse(time.RFC3339, proposal.Upgrade.EstimatedTime)
+	if err != nil {
+		return "", false
+	}
+
+	remaining := estimatedTime.Sub(now)
+	if remaining <= 0 {
+		return "", false
+	}
+	stage, reached := "", false
+	for _, s := range UpgradeStages {
+		if remaining <= s.Before {
+			stage, reached = s.Stage, true
+		}
+	}
+	return stage, reached
+}
+
+func snapshotOf(state ChainState, proposal proposals.Proposal) proposals.ProposalSnapshot {
+	snapshot := proposals.ProposalSnapshot{
+		ProposalID:    proposal.ProposalID,
+		Status:        proposal.Status,
+		VotingEndTime: proposal.VotingEndTime,
+		Expedited:     proposal.Expedited,
+	}
+	votes := voteStatus(state, proposal)
+	previous := state.Previous[proposal.ProposalID]
+	snapshot.Reminders = previous.Reminders
+	for _, voter := range state.Voters {
+		if !votes[voter] {
+			continue
+		}
+		snapshot.VotedBy = append(snapshot.VotedBy, voter)
+		if vote := state.Votes[proposal.ProposalID][voter]; vote != nil {
+			snapshot.Votes = append(snapshot.Votes, *vote)
+		} else if vote := previous.VoteOf(voter); vote != nil {
+			snapshot.Votes = append(snapshot.Votes, *vote)
+		}
+	}
+	snapshot.Voted = len(state.Voters) > 0 && len(snapshot.VotedBy) == len(state.Voters)
+
+	for _, voter := range state.Voters {
+		changes := previous.VoteChanges[voter]
+		if voteChanged(state, proposal, voter) {
+			changes++
+		}
+		if changes == 0 {
+			continue
+		}
+		if snapshot.VoteChanges == nil {
+			snapshot.VoteChanges = make(map[string]int)
+		}
+		snapshot.VoteChanges[voter] = changes
+	}
+	return snapshot
+}
+
+// voteChanged reports whether the vote of the voter checked during this run differs from the one in
+// the last snapshot
+func voteChanged(state ChainState, proposal proposals.Proposal, voter string) bool {
+	vote := state.Votes[proposal.ProposalID][voter]
+	previous, known := state.Previous[proposal.ProposalID]
+	if vote == nil || !known || previous.VoteOf(voter) == nil {
+		return false
+	}
+	return !vote.SameOptions(previous.VoteOf(voter))
+}
+
+// voteStatus returns the vote status of every voter it is known for: checked during this run, or
+// voted according to the last snapshot, since votes can't be withdrawn
+func voteStatus(state ChainState, proposal proposals.Proposal) map[string]bool {
+	votes := make(map[string]bool)
+	previous, known := state.Previous[proposal.ProposalID]
+	for _, voter := range state.Voters {
+		if vote, checked := state.Votes[proposal.ProposalID][voter]; checked {
+			votes[voter] = vote != nil
+		} else if known && previous.HasVoted(voter) {
+			votes[voter] = true
+		}
+	}
+	return votes
+}
@@ -0,0 +1,215 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"tendermint_proposal_monitor/proposals"
+)
+
+const voter = "cosmos1voter"
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func votingProposal(id string, votingEnd time.Duration) proposals.Proposal {
+	return proposals.Proposal{
+		ProposalID:      id,
+		Status:          proposals.ProposalStatusVotingPeriod,
+		VotingStartTime: now.Add(votingEnd - 14*24*time.Hour).Format(time.RFC3339),
+		VotingEndTime:   now.Add(votingEnd).Format(time.RFC3339),
+	}
+}
+
+func votingSnapshot(id string, votes ...proposals.Vote) proposals.ProposalSnapshot {
+	snapshot := proposals.ProposalSnapshot{ProposalID: id, Status: proposals.ProposalStatusVotingPeriod, VotedBy: []string{}}
+	for _, vote := range votes {
+		snapshot.VotedBy = append(snapshot.VotedBy, vote.Voter)
+		snapshot.Votes = append(snapshot.Votes, vote)
+	}
+	return snapshot
+}
+
+func voteOf(option string) *proposals.Vote {
+	return &proposals.Vote{Voter: voter, Options: []proposals.WeightedOption{{Option: option, Weight: "1"}}}
+}
+
+func TestDiff(t *testing.T) {
+	expedited := votingProposal("7", 5*time.Hour)
+	expedited.Expedited = true
+	expedited.VotingStartTime = now.Add(5*time.Hour - 24*time.Hour).Format(time.RFC3339)
+	converted := votingProposal("7", 10*24*time.Hour)
+	expeditedSnapshot := votingSnapshot("7")
+	expeditedSnapshot.Expedited = true
+	changedBefore := votingSnapshot("7", *voteOf("VOTE_OPTION_NO"))
+	changedBefore.VoteChanges = map[string]int{voter: 1}
+	passed := votingProposal("7", -time.Hour)
+	passed.Status = proposals.ProposalStatusPassed
+
+	tests := []struct {
+		name    string
+		state   ChainState
+		current []proposals.Proposal
+		want    []string
+	}{
+		{
+			name:    "submitted",
+			state:   ChainState{LastChecked: 6},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+			want:    []string{"7/proposal_submitted"},
+		},
+		{
+			name:    "already checked",
+			state:   ChainState{LastChecked: 7, Previous: map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7")}},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+		},
+		{
+			name:    "submitted after it closed",
+			state:   ChainState{LastChecked: 6},
+			current: []proposals.Proposal{passed},
+		},
+		{
+			name:    "voting started",
+			state:   ChainState{LastChecked: 7, Previous: map[string]proposals.ProposalSnapshot{"7": {ProposalID: "7", Status: proposals.ProposalStatusDepositPeriod}}},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+			want:    []string{"7/voting_started"},
+		},
+		{
+			name:    "nearing window",
+			state:   ChainState{LastChecked: 7, Previous: map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7")}},
+			current: []proposals.Proposal{votingProposal("7", 23*time.Hour)},
+			want:    []string{"7/deadline_approaching"},
+		},
+		{
+			name:    "nearing window of a short voting period",
+			state:   ChainState{LastChecked: 7, Previous: map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7")}, Params: &proposals.GovParams{VotingPeriod: 48 * time.Hour}},
+			current: []proposals.Proposal{votingProposal("7", 13*time.Hour)},
+		},
+		{
+			name:    "nearing window of an expedited proposal",
+			state:   ChainState{LastChecked: 7, Previous: map[string]proposals.ProposalSnapshot{"7": expeditedSnapshot}},
+			current: []proposals.Proposal{expedited},
+			want:    []string{"7/deadline_approaching/expedited"},
+		},
+		{
+			name:    "expedited converted",
+			state:   ChainState{LastChecked: 7, Previous: map[string]proposals.ProposalSnapshot{"7": expeditedSnapshot}},
+			current: []proposals.Proposal{converted},
+			want:    []string{"7/expedited_converted"},
+		},
+		{
+			name: "vote detected",
+			state: ChainState{
+				LastChecked: 7,
+				Previous:    map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7")},
+				Voters:      []string{voter},
+				Votes:       map[string]map[string]*proposals.Vote{"7": {voter: voteOf("VOTE_OPTION_YES")}},
+			},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+			want:    []string{"7/vote_detected/" + voter},
+		},
+		{
+			name: "vote unchanged",
+			state: ChainState{
+				LastChecked: 7,
+				Previous:    map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7", *voteOf("VOTE_OPTION_YES"))},
+				Voters:      []string{voter},
+				Votes:       map[string]map[string]*proposals.Vote{"7": {voter: voteOf("1")}},
+			},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+		},
+		{
+			name: "vote changed",
+			state: ChainState{
+				LastChecked: 7,
+				Previous:    map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7", *voteOf("VOTE_OPTION_YES"))},
+				Voters:      []string{voter},
+				Votes:       map[string]map[string]*proposals.Vote{"7": {voter: voteOf("VOTE_OPTION_NO")}},
+			},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+			want:    []string{"7/vote_changed/" + voter + "/1"},
+		},
+		{
+			name: "vote changed back",
+			state: ChainState{
+				LastChecked: 7,
+				Previous:    map[string]proposals.ProposalSnapshot{"7": changedBefore},
+				Voters:      []string{voter},
+				Votes:       map[string]map[string]*proposals.Vote{"7": {voter: voteOf("VOTE_OPTION_YES")}},
+			},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+			want:    []string{"7/vote_changed/" + voter + "/2"},
+		},
+		{
+			name:    "closed",
+			state:   ChainState{LastChecked: 7, Previous: map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7")}},
+			current: []proposals.Proposal{passed},
+			want:    []string{"7/proposal_closed"},
+		},
+		{
+			name: "cancelled",
+			state: ChainState{
+				LastChecked:         8,
+				Previous:            map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7"), "8": votingSnapshot("8")},
+				DetectCancellations: true,
+			},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+			want:    []string{"8/proposal_cancelled"},
+		},
+		{
+			name: "older than the fetched page",
+			state: ChainState{
+				LastChecked:         8,
+				Previous:            map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7"), "8": votingSnapshot("8")},
+				DetectCancellations: true,
+			},
+			current: []proposals.Proposal{votingProposal("8", 10*24*time.Hour)},
+		},
+		{
+			name: "cancelled on a source that keeps them",
+			state: ChainState{
+				LastChecked: 8,
+				Previous:    map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7"), "8": votingSnapshot("8")},
+			},
+			current: []proposals.Proposal{votingProposal("7", 10*24*time.Hour)},
+		},
+	}
+
+	engine := NewEngine(24 * time.Hour)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var keys []string
+			for _, event := range engine.Diff(test.state, test.current, now) {
+				keys = append(keys, event.Key())
+			}
+			if !reflect.DeepEqual(keys, test.want) {
+				t.Errorf("Diff events = %v, want %v", keys, test.want)
+			}
+		})
+	}
+}
+
+// A vote changed back and forth is announced on every change, each under a key of its own
+func TestDiffVoteChangedBackAndForth(t *testing.T) {
+	engine := NewEngine(24 * time.Hour)
+	proposal := votingProposal("7", 10*24*time.Hour)
+	state := ChainState{
+		LastChecked: 7,
+		Previous:    map[string]proposals.ProposalSnapshot{"7": votingSnapshot("7", *voteOf("VOTE_OPTION_YES"))},
+		Voters:      []string{voter},
+	}
+
+	seen := make(map[string]bool)
+	for _, option := range []string{"VOTE_OPTION_NO", "VOTE_OPTION_YES", "VOTE_OPTION_NO"} {
+		state.Votes = map[string]map[string]*proposals.Vote{"7": {voter: voteOf(option)}}
+		events := engine.Diff(state, []proposals.Proposal{proposal}, now)
+		if len(events) != 1 || events[0].Type != VoteChanged {
+			t.Fatalf("Diff events for a change to %s = %v, want a vote change", option, events)
+		}
+		if seen[events[0].Key()] {
+			t.Errorf("change to %s has the key %s of an earlier change", option, events[0].Key())
+		}
+		seen[events[0].Key()] = true
+		state.Previous = engine.Snapshot(state, []proposals.Proposal{proposal})
+	}
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tendermint_proposal_monitor/proposals"
+)
+
+// Type identifies a governance lifecycle event
+type Type string
+
+const (
+	ProposalSubmitted   Type = "proposal_submitted"
+	VotingStarted       Type = "voting_started"
+	DeadlineApproaching Type = "deadline_approaching"
+	VoteDetected        Type = "vote_detected"
+	VoteChanged         Type = "vote_changed"
+	ProposalClosed      Type = "proposal_closed"
+	ProposalCancelled   Type = "proposal_cancelled"
+	ExpeditedConverted  Type = "expedited_converted"
+	UpgradeApproaching  Type = "upgrade_approaching"
+)
+
+// Event is a single change detected between the last known snapshot of a chain and a fresh fetch
+type Event struct {
+	Type Type `json:"type"`
+	// Stage distinguishes events of the same type that may happen more than once for a proposal
+	Stage     string                      `json:"stage,omitempty"`
+	ChainName string                      `json:"chain_name"`
+	Proposal  proposals.Proposal          `json:"proposal"`
+	Snapshot  proposals.ProposalSnapshot  `json:"snapshot"`
+	Previous  *proposals.ProposalSnapshot `json:"previous,omitempty"`
+	// Votes holds the vote status of every voter it is known for, and VoteKnown is set when it is
+	// known for all of them
+	Votes     map[string]bool `json:"votes,omitempty"`
+	VoteKnown bool            `json:"vote_known"`
+	// Voter is the address a vote was detected or changed from, and Vote how it voted
+	Voter      string          `json:"voter,omitempty"`
+	Vote       *proposals.Vote `json:"vote,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// Key uniquely identifies the event within its chain so it is only ever emitted once
+func (e Event) Key() string {
+	if e.Stage != "" {
+		return fmt.Sprintf("%s/%s/%s", e.Proposal.ProposalID, e.Type, e.Stage)
+	}
+	return fmt.Sprintf("%s/%s", e.Proposal.ProposalID, e.Type)
+}
+
+// Subscriber receives published events. Returning an error stops delivery to later subscribers
+// and leaves the event unrecorded so it is retried on the next run.
+type Subscriber interface {
+	Handle(ctx context.Context, event Event) error
+}
+
+type SubscriberFunc func(ctx context.Context, event Event) error
+
+func (f SubscriberFunc) Handle(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+type subscription struct {
+	name       string
+	subscriber Subscriber
+	types      map[Type]bool
+}
+
+// Ledger records which named subscribers an event was delivered to
+type Ledger interface {
+	Delivered(event Event, subscriber string) bool
+	RecordDelivery(ctx context.Context, event Event, subscriber string)
+}
+
+// Bus delivers events to its subscribers in the order they subscribed. With a Ledger, named
+// subscribers that handled an event aren't delivered it again when it is retried after a later
+// subscriber failed.
+type Bus struct {
+	Ledger        Ledger
+	subscriptions []subscription
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a subscriber for the given event types, or for every event if none are given
+func (b *Bus) Subscribe(subscriber Subscriber, types ...Type) {
+	b.SubscribeAs("", subscriber, types...)
+}
+
+// SubscribeAs registers a subscriber under a name, by which its deliveries are recorded in the ledger
+func (b *Bus) SubscribeAs(name string, subscriber Subscriber, types ...Type) {
+	sub := subscription{name: name, subscriber: subscriber}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool)
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.subscriptions = append(b.subscriptions, sub)
+}
+
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	for _, sub := range b.subscriptions {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+		recorded := sub.name != "" && b.Ledger != nil
+		if recorded && b.Ledger.Delivered(event, sub.name) {
+			continue
+		}
+		err := sub.subscriber.Handle(ctx, event)
+		if err != nil {
+			return fmt.Errorf("error handling %s event for proposal %s on %s: %v", event.Type, event.Proposal.ProposalID, event.ChainName, err)
+		}
+		if recorded {
+			b.Ledger.RecordDelivery(ctx, event, sub.name)
+		}
+	}
+	return nil
+}
+
+// Handle lets a bus subscribe to another bus
+func (b *Bus) Handle(ctx context.Context, event Event) error {
+	return b.Publish(ctx, event)
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Feed keeps the most recent events in memory so they can be served through the API
+type Feed struct {
+	mu     sync.Mutex
+	limit  int
+	events []Event
+}
+
+func NewFeed(limit int) *Feed {
+	return &Feed{limit: limit}
+}
+
+func (f *Feed) Handle(ctx context.Context, event Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.events = append(f.events, event)
+	if len(f.events) > f.limit {
+		f.events = f.events[len(f.events)-f.limit:]
+	}
+	return nil
+}
+
+// Recent returns the buffered events, newest first
+func (f *Feed) Recent() []Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	recent := make([]Event, 0, len(f.events))
+	for i := len(f.events) - 1; i >= 0; i-- {
+		recent = append(recent, f.events[i])
+	}
+	return recent
+}
@@ -1,25 +1,61 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"tendermint_proposal_monitor/chainclient"
 	"tendermint_proposal_monitor/config"
+	"tendermint_proposal_monitor/events"
 	"tendermint_proposal_monitor/monitor"
+	"tendermint_proposal_monitor/notifiers"
 	"tendermint_proposal_monitor/proposals"
 	"tendermint_proposal_monitor/services"
+	"time"
 )
 
 var (
-	cfg       *config.Configurations
-	globalErr error
-	useMock   bool
+	cfg         *config.Configurations
+	globalErr   error
+	useMock     bool
+	eventFeed   = events.NewFeed(100)
+	chainClient *chainclient.Client
+	govParams   *proposals.GovParamsCache
+	// Mock runs answer chain queries from fixtures and keep their state in memory
+	mockServices *services.NewServices
+	// replayTime is when the replayed recording was made, zero unless replaying
+	replayTime time.Time
+	// prepareVote prints an unsigned vote transaction instead of starting the server
+	prepareVote bool
+	voteTxOpts  proposals.VoteTxOptions
+	voteTxChain string
+	voteTxFees  string
+	voteTxOut   string
 )
 
+// DefaultMockFixturesDir is used when mock_fixtures_dir isn't configured
+const DefaultMockFixturesDir = "src/fixtures"
+
 func init() {
-	flag.BoolVar(&useMock, "mock", false, "Use mock data for testing")
+	flag.BoolVar(&useMock, "mock", false, "Use fixtures instead of chain queries and storage for testing")
 	configFile := flag.String("config", getEnv("CONFIG_FILE", "src/config/config.yml"), "Path to configuration file")
+	recordDir := flag.String("record", "", "Record the responses of chain endpoints to this directory")
+	replayDir := flag.String("replay", "", "Run in mock mode against responses recorded to this directory")
+	flag.BoolVar(&prepareVote, "prepare-vote", false, "Print an unsigned vote transaction for --chain and --proposal instead of starting the server")
+	flag.StringVar(&voteTxChain, "chain", "", "Chain of the prepared vote")
+	flag.StringVar(&voteTxOpts.ProposalID, "proposal", "", "Proposal ID of the prepared vote")
+	flag.StringVar(&voteTxOpts.Option, "option", "", "Option of the prepared vote: yes, no, abstain or no_with_veto")
+	flag.StringVar(&voteTxOpts.Voter, "voter", "", "Voter of the prepared vote, the chain's first voter by default")
+	flag.StringVar(&voteTxOpts.Grantee, "grantee", "", "Authz grantee signing the prepared vote, the voter's grantee by default")
+	flag.Uint64Var(&voteTxOpts.GasLimit, "gas", proposals.DefaultVoteGasLimit, "Gas limit of the prepared vote")
+	flag.StringVar(&voteTxFees, "fees", "", "Fees of the prepared vote, e.g. 5000uatom")
+	flag.StringVar(&voteTxOut, "out", "", "File the prepared vote is written to, standard output by default")
 	flag.Parse()
 
 	log.Println("Starting Proposal Monitor Service...")
@@ -31,6 +67,31 @@ func init() {
 	}
 
 	log.Printf("Configuration loaded successfully.")
+
+	chainClient = chainclient.New(cfg.HTTP)
+	if *recordDir != "" {
+		log.Printf("Recording chain responses to %s", *recordDir)
+		chainClient.HTTPClient.Transport = chainclient.NewRecordingTransport(chainClient.HTTPClient.Transport, *recordDir, cfg.Chains, cfg.RecordScrub)
+	}
+	govParams = proposals.NewGovParamsCache(chainClient)
+
+	fixturesDir := cfg.MockFixturesDir
+	if fixturesDir == "" {
+		fixturesDir = DefaultMockFixturesDir
+	}
+	if *replayDir != "" {
+		recording, err := chainclient.LoadRecording(*replayDir)
+		if err != nil {
+			log.Fatalf("Error loading recording: %v", err)
+		}
+		log.Printf("Replaying chain responses recorded at %s", recording.RecordedAt.Format(time.RFC3339))
+		fixturesDir = *replayDir
+		replayTime = recording.RecordedAt
+		useMock = true
+	}
+	mockClient := chainclient.NewFixtureClient(cfg.HTTP, fixturesDir, cfg.Chains)
+	mockServices = services.New(proposals.NewMemoryStore(), mockClient, proposals.NewGovParamsCache(mockClient), cfg)
+	mockServices.AlertHTTPClient = &http.Client{Transport: notifiers.LogTransport{}}
 }
 
 func getEnv(key, fallback string) string {
@@ -47,27 +108,283 @@ func triggerMonitor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	firestoreHandler, err := proposals.New(cfg)
+	// Check for mock query parameter
+	mock := useMock || r.URL.Query().Get("mock") == "true"
+
+	s, err := servicesFor(mock)
 	if err != nil {
 		log.Printf("Error creating FirestoreHandler: %v", err)
 		http.Error(w, "Error creating FirestoreHandler", http.StatusInternalServerError)
 		return
 	}
-	s := services.New(firestoreHandler, cfg)
 	h := monitor.NewHandler(s)
+	h.Bus.Subscribe(eventFeed)
+	if mock && !replayTime.IsZero() {
+		h.Now = func() time.Time { return replayTime }
+	}
 
-	// Check for mock query parameter
-	mock := r.URL.Query().Get("mock")
-	useMock := mock == "true"
-
-	err = h.Run(cfg, useMock)
+	result, err := h.Run(r.Context(), cfg)
 	if err != nil {
 		log.Printf("Error running monitor: %v", err)
 		http.Error(w, "Error running monitor", http.StatusInternalServerError)
 		return
 	}
 
-	w.Write([]byte("Monitor triggered successfully"))
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		log.Printf("Error encoding run result: %v", err)
+	}
+}
+
+// servicesFor returns the services of mock runs, kept in memory, or of production runs, backed by
+// Firestore
+func servicesFor(mock bool) (*services.NewServices, error) {
+	if mock {
+		return mockServices, nil
+	}
+	firestoreHandler, err := proposals.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return services.New(firestoreHandler, chainClient, govParams, cfg), nil
+}
+
+// participationReport reports on the participation of our voters in the proposals whose voting
+// period ended between the from and to dates, both included, of one chain or of all of them
+func participationReport(w http.ResponseWriter, r *http.Request) {
+	if globalErr != nil {
+		http.Error(w, "Configuration error, unable to build report", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := parseReportDate(query.Get("from"), false)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid from date: %v", err), http.StatusBadRequest)
+		return
+	}
+	to, err := parseReportDate(query.Get("to"), true)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid to date: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s, err := servicesFor(useMock || query.Get("mock") == "true")
+	if err != nil {
+		log.Printf("Error creating FirestoreHandler: %v", err)
+		http.Error(w, "Error creating FirestoreHandler", http.StatusInternalServerError)
+		return
+	}
+	history, err := s.Store.GetVoteHistory(r.Context())
+	if err != nil {
+		log.Printf("Error loading vote history: %v", err)
+		http.Error(w, "Error loading vote history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(monitor.BuildParticipationReports(history, query.Get("chain"), from, to))
+	if err != nil {
+		log.Printf("Error encoding participation report: %v", err)
+	}
+}
+
+// peerVotes shows how the validators with the most voting power voted on a proposal
+func peerVotes(w http.ResponseWriter, r *http.Request) {
+	if globalErr != nil {
+		http.Error(w, "Configuration error, unable to fetch peer votes", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	chainName := query.Get("chain")
+	chain, found := cfg.Chains[chainName]
+	if !found {
+		http.Error(w, fmt.Sprintf("Unknown chain %q", chainName), http.StatusNotFound)
+		return
+	}
+	if !proposals.IsCosmosSDK(chain) {
+		http.Error(w, fmt.Sprintf("Peer votes aren't available on chain %s", chainName), http.StatusBadRequest)
+		return
+	}
+	proposalID := query.Get("proposal")
+	if proposalID == "" {
+		http.Error(w, "Missing proposal", http.StatusBadRequest)
+		return
+	}
+	count := chain.PeerValidators
+	if count <= 0 {
+		count = proposals.DefaultPeerValidators
+	}
+	if value := query.Get("count"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid count %q", value), http.StatusBadRequest)
+			return
+		}
+		count = parsed
+	}
+
+	s, err := servicesFor(useMock || query.Get("mock") == "true")
+	if err != nil {
+		log.Printf("Error creating FirestoreHandler: %v", err)
+		http.Error(w, "Error creating FirestoreHandler", http.StatusInternalServerError)
+		return
+	}
+	votes, err := proposals.FetchPeerVotes(r.Context(), s.ChainClient, chain, proposalID, count)
+	if err != nil {
+		log.Printf("Error fetching peer votes of proposal %s on chain %s: %v", proposalID, chainName, err)
+		http.Error(w, "Error fetching peer votes", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(struct {
+		ChainName  string                      `json:"chain_name"`
+		ProposalID string                      `json:"proposal_id"`
+		Summary    []proposals.PeerVoteSummary `json:"summary"`
+		Validators []proposals.PeerVote        `json:"validators"`
+	}{chainName, proposalID, proposals.SummarizePeerVotes(votes), votes})
+	if err != nil {
+		log.Printf("Error encoding peer votes: %v", err)
+	}
+}
+
+// voteTx prepares an unsigned vote transaction for the signers of a chain, with the account number
+// and sequence to sign it offline with
+func voteTx(w http.ResponseWriter, r *http.Request) {
+	if globalErr != nil {
+		http.Error(w, "Configuration error, unable to prepare vote", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	chainName := query.Get("chain")
+	chain, found := cfg.Chains[chainName]
+	if !found {
+		http.Error(w, fmt.Sprintf("Unknown chain %q", chainName), http.StatusNotFound)
+		return
+	}
+	if !proposals.IsCosmosSDK(chain) {
+		http.Error(w, fmt.Sprintf("Vote transactions can't be prepared for chain %s", chainName), http.StatusBadRequest)
+		return
+	}
+
+	opts := proposals.VoteTxOptions{
+		ProposalID: query.Get("proposal"),
+		Option:     query.Get("option"),
+		Voter:      query.Get("voter"),
+		Grantee:    query.Get("grantee"),
+		Memo:       query.Get("memo"),
+	}
+	if value := query.Get("gas"); value != "" {
+		gasLimit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid gas %q", value), http.StatusBadRequest)
+			return
+		}
+		opts.GasLimit = gasLimit
+	}
+	fees, err := proposals.ParseCoins(query.Get("fees"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid fees: %v", err), http.StatusBadRequest)
+		return
+	}
+	opts.Fees = fees
+	if _, err := proposals.ParseVoteOption(opts.Option); err != nil || opts.ProposalID == "" {
+		http.Error(w, "A proposal and an option of yes, no, abstain or no_with_veto are required", http.StatusBadRequest)
+		return
+	}
+	// The endpoint is public, so it only prepares votes of our own voters
+	if !proposals.IsConfiguredVoter(chain, opts.Voter) {
+		http.Error(w, fmt.Sprintf("%q isn't a voter configured for chain %s", opts.Voter, chainName), http.StatusForbidden)
+		return
+	}
+
+	s, err := servicesFor(useMock || query.Get("mock") == "true")
+	if err != nil {
+		log.Printf("Error creating FirestoreHandler: %v", err)
+		http.Error(w, "Error creating FirestoreHandler", http.StatusInternalServerError)
+		return
+	}
+	unsigned, err := proposals.BuildUnsignedVoteTx(r.Context(), s.ChainClient, chain, opts)
+	if err != nil {
+		log.Printf("Error preparing vote on proposal %s of chain %s: %v", opts.ProposalID, chainName, err)
+		http.Error(w, fmt.Sprintf("Error preparing vote: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(unsigned)
+	if err != nil {
+		log.Printf("Error encoding vote transaction: %v", err)
+	}
+}
+
+// runPrepareVote writes the unsigned vote transaction asked for on the command line, ready for
+// `<chaind> tx sign`, and prints how to sign it
+func runPrepareVote() error {
+	chain, found := cfg.Chains[voteTxChain]
+	if !found {
+		return fmt.Errorf("unknown chain %q", voteTxChain)
+	}
+	fees, err := proposals.ParseCoins(voteTxFees)
+	if err != nil {
+		return fmt.Errorf("invalid fees: %v", err)
+	}
+	voteTxOpts.Fees = fees
+
+	client := chainClient
+	if useMock {
+		client = mockServices.ChainClient
+	}
+	unsigned, err := proposals.BuildUnsignedVoteTx(context.Background(), client, chain, voteTxOpts)
+	if err != nil {
+		return err
+	}
+
+	tx, err := json.MarshalIndent(unsigned.Tx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if voteTxOut == "" {
+		fmt.Println(string(tx))
+	} else if err := os.WriteFile(voteTxOut, append(tx, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	log.Printf("Prepared vote of %s on proposal %s, signed by %s with account number %s and sequence %s", unsigned.Voter, unsigned.ProposalID, unsigned.Signer, unsigned.AccountNumber, unsigned.Sequence)
+	signCommand := unsigned.SignCommand
+	if voteTxOut != "" {
+		signCommand = strings.Replace(signCommand, proposals.VoteTxFile, voteTxOut, 1)
+	}
+	log.Printf("Sign it with: %s", signCommand)
+	return nil
+}
+
+// parseReportDate accepts a date, or a time in RFC 3339 format, with an empty value leaving that end
+// of the range open. As the range excludes its end, a date ending it is taken as the following
+// midnight, so the whole day is included.
+func parseReportDate(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		if end {
+			return date.AddDate(0, 0, 1), nil
+		}
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func recentEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(eventFeed.Recent())
+	if err != nil {
+		log.Printf("Error encoding events: %v", err)
+	}
 }
 
 func healthcheck(w http.ResponseWriter, r *http.Request) {
@@ -76,7 +393,19 @@ func healthcheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	if prepareVote {
+		err := runPrepareVote()
+		if err != nil {
+			log.Fatalf("Error preparing vote: %v", err)
+		}
+		return
+	}
+
 	http.HandleFunc("/trigger-monitor", triggerMonitor)
+	http.HandleFunc("/events", recentEvents)
+	http.HandleFunc("/participation", participationReport)
+	http.HandleFunc("/peer-votes", peerVotes)
+	http.HandleFunc("/vote-tx", voteTx)
 	http.HandleFunc("/health", healthcheck)
 	log.Println("Server started on port 8080")
 	log.Fatal(http.ListenAndServe(":8080", nil))
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"strings"
 	"time"
 
 	"tendermint_proposal_monitor/config"
@@ -18,9 +19,22 @@ type AlertDetails struct {
 	TimeLeft                 string
 	Description              string
 	FormattedVotingStartTime string
+	ProposalType             string
 }
 
-func SendDiscordAlert(cfg *config.Configurations, chain config.ChainConfig, chainName string, proposal proposals.Proposal, globalDiscordNotifier *notifiers.DiscordNotifier, alertType string) error {
+// Labels of the proposal types shown in alerts
+const (
+	ProposalTypeExpedited = "⚡ Expedited"
+	ProposalTypeGroup     = "👥 Group proposal"
+	ProposalTypeDAO       = "🏛️ DAO proposal"
+	ProposalTypeGovParams = "⚙️ Changes governance parameters"
+)
+
+// DefaultDescriptionLength is used when description_length isn't configured
+const DefaultDescriptionLength = 120
+
+// SendDiscordAlert sends a proposal alert. Any extra sections are added before the proposal link.
+func SendDiscordAlert(cfg *config.Configurations, chain config.ChainConfig, chainName string, proposal proposals.Proposal, globalDiscordNotifier *notifiers.DiscordNotifier, alertType string, sections ...string) error {
 	discordNotifier, err := getDiscordNotifier(cfg, chain, chainName, globalDiscordNotifier)
 	if err != nil {
 		return err
@@ -31,15 +45,26 @@ func SendDiscordAlert(cfg *config.Configurations, chain config.ChainConfig, chai
 		return err
 	}
 
-	messageContent := fmt.Sprintf("**%s %s**: %s\n\n**Proposal title:** %s\n\n**Short text description:** %s\n\n**Vote start:** %s\n\n**Time left: %s**\n\n**Read full proposal details:**\n%s",
-		alertType, chainName, proposal.ProposalID, proposal.Title, alertDetails.Description, alertDetails.FormattedVotingStartTime, alertDetails.TimeLeft, alertDetails.ProposalDetail)
+	messageContent := fmt.Sprintf("**%s %s**: %s\n\n", alertType, chainName, proposal.ProposalID)
+	if alertDetails.ProposalType != "" {
+		messageContent += fmt.Sprintf("**Proposal type:** %s\n\n", alertDetails.ProposalType)
+	}
+	messageContent += fmt.Sprintf("**Proposal title:** %s\n\n**Short text description:** %s\n\n**Vote start:** %s\n\n**Time left: %s**\n\n",
+		proposal.Title, alertDetails.Description, alertDetails.FormattedVotingStartTime, alertDetails.TimeLeft)
+	if proposal.Upgrade != nil {
+		messageContent += fmt.Sprintf("**Upgrade:** %s at height %d\n\n%s", proposal.Upgrade.Name, proposal.Upgrade.Height, formatUpgradeInfo(proposal.Upgrade.ParsedInfo))
+	}
+	for _, section := range sections {
+		messageContent += section
+	}
+	messageContent += fmt.Sprintf("**Read full proposal details:**\n%s", alertDetails.ProposalDetail)
 
 	return sendDiscordMessage(discordNotifier, messageContent)
 }
 
 func getDiscordNotifier(cfg *config.Configurations, chain config.ChainConfig, chainName string, globalDiscordNotifier *notifiers.DiscordNotifier) (*notifiers.DiscordNotifier, error) {
 	if chain.Alerts.Discord.Enabled && chain.Alerts.Discord.Webhook != "" {
-		return &notifiers.DiscordNotifier{WebhookURL: chain.Alerts.Discord.Webhook}, nil
+		return &notifiers.DiscordNotifier{WebhookURL: chain.Alerts.Discord.Webhook, HTTPClient: globalDiscordNotifier.HTTPClient}, nil
 	} else if chain.Alerts.Discord.Enabled && (cfg.Discord.Enabled && cfg.Discord.Webhook != "") {
 		return globalDiscordNotifier, nil
 	} else {
@@ -50,7 +75,9 @@ func getDiscordNotifier(cfg *config.Configurations, chain config.ChainConfig, ch
 
 func generateAlertDetails(cfg *config.Configurations, chain config.ChainConfig, chainName string, proposal proposals.Proposal) (*AlertDetails, error) {
 	proposalDetail := utils.GenerateProposalDetailURL(cfg.ProposalDetailDomain, chainName, proposal.ProposalID)
-	if chain.ExplorerURL != "" {
+	if proposal.DetailURL != "" {
+		proposalDetail = proposal.DetailURL
+	} else if chain.ExplorerURL != "" {
 		if chain.ExplorerURL == "-" {
 			proposalDetail = "-"
 		} else {
@@ -64,9 +91,13 @@ func generateAlertDetails(cfg *config.Configurations, chain config.ChainConfig,
 	}
 	timeLeft := utils.FormatTimeLeft(endTime)
 
+	descriptionLength := cfg.DescriptionLength
+	if descriptionLength <= 3 {
+		descriptionLength = DefaultDescriptionLength
+	}
 	description := proposal.Description
-	if len(description) > 120 {
-		description = description[:117] + "..."
+	if len(description) > descriptionLength {
+		description = description[:descriptionLength-3] + "..."
 	}
 
 	votingStartTime, err := time.Parse(time.RFC3339, proposal.VotingStartTime)
@@ -76,22 +107,44 @@ func generateAlertDetails(cfg *config.Configurations, chain config.ChainConfig,
 
 	formattedVotingStartTime := votingStartTime.Format("2006-01-02 15:04")
 
+	var proposalTypes []string
+	if proposal.Expedited {
+		proposalTypes = append(proposalTypes, ProposalTypeExpedited)
+	}
+	if proposal.Source == proposals.SourceGroup {
+		proposalTypes = append(proposalTypes, ProposalTypeGroup)
+	}
+	if proposal.Source == proposals.SourceDAO {
+		proposalTypes = append(proposalTypes, ProposalTypeDAO)
+	}
+	if proposal.ChangesGovParams {
+		proposalTypes = append(proposalTypes, ProposalTypeGovParams)
+	}
+	proposalType := strings.Join(proposalTypes, ", ")
+
 	return &AlertDetails{
 		ProposalDetail:           proposalDetail,
 		TimeLeft:                 timeLeft,
 		Description:              description,
 		FormattedVotingStartTime: formattedVotingStartTime,
+		ProposalType:             proposalType,
 	}, nil
 }
 
 func sendDiscordMessage(discordNotifier *notifiers.DiscordNotifier, messageContent string) error {
+	return sendDiscordEmbed(discordNotifier, "", notifiers.MessageBoxColor, messageContent)
+}
+
+// sendDiscordEmbed sends the message in an embed of the color, with the content, such as a mention,
+// above it
+func sendDiscordEmbed(discordNotifier *notifiers.DiscordNotifier, content string, color int, messageContent string) error {
 	embed := notifiers.DiscordEmbed{
-		Color:       notifiers.MessageBoxColor,
+		Color:       color,
 		Description: messageContent,
 	}
 
 	discordMessage := notifiers.DiscordMessage{
-		Content: "",
+		Content: content,
 		TTS:     false,
 		Embeds:  []notifiers.DiscordEmbed{embed},
 	}
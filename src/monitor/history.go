@@ -0,0 +1,215 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"tendermint_proposal_monitor/events"
+	"tendermint_proposal_monitor/proposals"
+)
+
+// recordVoteHistory adds votes detected during the run to the participation history of the chain
+func (h *Handler) recordVoteHistory(ctx context.Context, pctx *ProcessProposalContext, event events.Event) error {
+	voter := voterOf(pctx.Stream.Voters, event.Voter)
+	err := h.saveVoteRecord(ctx, pctx, event.Proposal, voter, event.Vote, recordVoted)
+	if err != nil {
+		return fmt.Errorf("error saving vote history: %v", err)
+	}
+	return nil
+}
+
+// maxBackfilledVotes caps the votes looked up per stream and run to fill in the history of proposals
+// closed before it was recorded, so a long list of past proposals is filled in over several runs
+const maxBackfilledVotes = 20
+
+// backfillVoteHistory records the votes of our voters on closed proposals missing from the history,
+// such as those that ended before the monitor recorded history, looking each vote up on chain. A vote
+// that isn't found is only recorded as missed when the transactions of the proposal are all indexed,
+// since votes are pruned once voting ends; otherwise its status is recorded as unknown.
+func (h *Handler) backfillVoteHistory(ctx context.Context, pctx *ProcessProposalContext, propList []proposals.Proposal, skip map[string]bool) error {
+	history, err := h.voteHistory(ctx)
+	if err != nil {
+		return err
+	}
+	recorded := make(map[string]bool)
+	for _, record := range history {
+		recorded[record.Key()] = true
+	}
+
+	lookups := 0
+	for _, proposal := range propList {
+		if !proposals.IsFinalStatus(proposal.Status) || skip[proposal.ProposalID] {
+			continue
+		}
+		votingEndTime, err := time.Parse(time.RFC3339, proposal.VotingEndTime)
+		if err != nil || votingEndTime.Year() <= 1 || votingEndTime.After(h.Now()) {
+			continue
+		}
+
+		for _, voter := range pctx.Stream.Voters {
+			key := proposals.VoteRecord{StateKey: pctx.Stream.StateKey, ProposalID: proposal.ProposalID, Voter: voter.Address}.Key()
+			if recorded[key] {
+				continue
+			}
+			if lookups >= maxBackfilledVotes {
+				return nil
+			}
+			lookups++
+
+			vote, err := pctx.Stream.GetVote(ctx, proposal.ProposalID, voter.Address)
+			status := recordVoted
+			switch {
+			case errors.Is(err, proposals.ErrVoteUnconfirmed):
+				status = recordUnknown
+			case err != nil:
+				// Tried again on the next run
+				log.Printf("Error looking up past vote of %s for proposal %s on %s: %v", voter.Label, proposal.ProposalID, pctx.Stream.StateKey, err)
+				continue
+			case vote == nil:
+				status, err = h.missingVoteStatus(ctx, pctx, proposal.ProposalID)
+				if err != nil {
+					log.Printf("Error checking the transaction index for proposal %s on %s: %v", proposal.ProposalID, pctx.Stream.StateKey, err)
+					continue
+				}
+			}
+
+			err = h.saveVoteRecord(ctx, pctx, proposal, voter, vote, status)
+			if err != nil {
+				return fmt.Errorf("error saving vote history: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// missingVoteStatus tells whether a vote not found on a closed proposal was missed, which is only
+// certain where its votes aren't pruned or the transactions of the proposal are all indexed
+func (h *Handler) missingVoteStatus(ctx context.Context, pctx *ProcessProposalContext, proposalID string) (voteRecordStatus, error) {
+	if pctx.Stream.TxsIndexed == nil {
+		return recordMissed, nil
+	}
+	indexed, err := pctx.Stream.TxsIndexed(ctx, proposalID)
+	if err != nil {
+		return recordUnknown, err
+	}
+	if !indexed {
+		return recordUnknown, nil
+	}
+	return recordMissed, nil
+}
+
+// voteRecordStatus is what is known of the vote of a history record
+type voteRecordStatus int
+
+const (
+	recordVoted voteRecordStatus = iota
+	recordMissed
+	recordUnknown
+)
+
+// saveVoteRecord updates the history record of the voter for the proposal. The time of the vote is
+// taken from its transaction where it can be found, and is only looked up again while it is estimated.
+func (h *Handler) saveVoteRecord(ctx context.Context, pctx *ProcessProposalContext, proposal proposals.Proposal, voter Voter, vote *proposals.Vote, status voteRecordStatus) error {
+	history, err := h.voteHistory(ctx)
+	if err != nil {
+		return err
+	}
+
+	record := proposals.VoteRecord{
+		StateKey:   pctx.Stream.StateKey,
+		ChainName:  pctx.ChainName,
+		ProposalID: proposal.ProposalID,
+		Voter:      voter.Address,
+	}
+	for _, existing := range history {
+		if existing.Key() == record.Key() {
+			record = existing
+			break
+		}
+	}
+	record.Title = proposal.Title
+	record.Status = proposal.Status
+	record.VotingStartTime = proposal.VotingStartTime
+	record.VotingEndTime = proposal.VotingEndTime
+	record.VoterLabel = voter.Label
+	record.Missed = status == recordMissed
+	record.VoteStatusUnknown = status == recordUnknown
+
+	if status == recordVoted {
+		if vote != nil {
+			record.Vote = vote
+		}
+		if record.VotedAt == nil || record.VotedAtEstimated {
+			tx, err := h.findVoteTx(ctx, pctx, proposal.ProposalID, voter.Address)
+			if err != nil {
+				log.Printf("Error searching vote transaction of %s for proposal %s on %s: %v", voter.Address, proposal.ProposalID, pctx.Stream.StateKey, err)
+			}
+			if votedAt, ok := voteTxTime(tx); ok {
+				record.VotedAt = &votedAt
+				record.VotedAtEstimated = false
+				record.TxHash = tx.TxHash
+			} else if record.VotedAt == nil && proposal.Status == proposals.ProposalStatusVotingPeriod {
+				// Votes on closed proposals were cast at an unknown time before now, so they are left
+				// without a time rather than given a wrong one
+				now := h.Now()
+				record.VotedAt = &now
+				record.VotedAtEstimated = true
+			}
+		}
+	}
+
+	err = h.Services.Store.SaveVoteRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+	for i := range h.history {
+		if h.history[i].Key() == record.Key() {
+			h.history[i] = record
+			return nil
+		}
+	}
+	h.history = append(h.history, record)
+	return nil
+}
+
+func voteTxTime(tx *proposals.VoteTx) (time.Time, bool) {
+	if tx == nil {
+		return time.Time{}, false
+	}
+	votedAt, err := time.Parse(time.RFC3339, tx.Timestamp)
+	return votedAt, err == nil
+}
+
+// voteHistory loads the participation history once per run
+func (h *Handler) voteHistory(ctx context.Context) ([]proposals.VoteRecord, error) {
+	if h.history != nil {
+		return h.history, nil
+	}
+	history, err := h.Services.Store.GetVoteHistory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading vote history: %v", err)
+	}
+	h.history = append([]proposals.VoteRecord{}, history...)
+	return h.history, nil
+}
+
+// findVoteTx searches the transaction of a vote once per run, since both the vote alert and the
+// history need it
+func (h *Handler) findVoteTx(ctx context.Context, pctx *ProcessProposalContext, proposalID string, voter string) (*proposals.VoteTx, error) {
+	if pctx.Stream.VoteTx == nil {
+		return nil, nil
+	}
+	key := proposalID + "/" + voter
+	if tx, found := pctx.VoteTxs[key]; found {
+		return tx, nil
+	}
+	tx, err := pctx.Stream.VoteTx(ctx, proposalID, voter)
+	if err != nil {
+		return nil, err
+	}
+	pctx.VoteTxs[key] = tx
+	return tx, nil
+}
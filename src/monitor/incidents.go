@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"tendermint_proposal_monitor/events"
+	"tendermint_proposal_monitor/notifiers"
+	"tendermint_proposal_monitor/proposals"
+)
+
+const (
+	AlertTypeMissedVote = "🚨 Missed vote on"
+	// An incident whose vote couldn't be confirmed once voting ended isn't known to be a missed vote
+	AlertTypeUnconfirmedVote = "⚠️ Vote not confirmed on"
+)
+
+// recordReminder keeps the reminder sent for the event, to be listed in the incident if the
+// proposal's voting period ends without our vote
+func (h *Handler) recordReminder(pctx *ProcessProposalContext, event events.Event) {
+	alert := string(event.Type)
+	if event.Stage != "" {
+		alert = fmt.Sprintf("%s/%s", alert, event.Stage)
+	}
+	proposalID := event.Proposal.ProposalID
+	pctx.Reminders[proposalID] = append(pctx.Reminders[proposalID], proposals.Reminder{Alert: alert, SentAt: h.Now()})
+}
+
+// recordClosedVotes records the final vote of every voter in the history once a proposal's voting
+// period ended, and an incident for every voter that hadn't voted, to be alerted once recorded. The
+// vote is looked up once more first, since it may have been cast after the last run.
+func (h *Handler) recordClosedVotes(ctx context.Context, pctx *ProcessProposalContext, event events.Event) error {
+	if event.Previous == nil || event.Previous.Status != proposals.ProposalStatusVotingPeriod {
+		return nil
+	}
+
+	pctx.Incidents[event.Proposal.ProposalID] = []proposals.Incident{}
+	for _, voter := range pctx.Stream.Voters {
+		if event.Snapshot.HasVoted(voter.Address) {
+			err := h.saveVoteRecord(ctx, pctx, event.Proposal, voter, event.Snapshot.VoteOf(voter.Address), recordVoted)
+			if err != nil {
+				return fmt.Errorf("error saving vote history: %v", err)
+			}
+			continue
+		}
+
+		// A vote that isn't found was only missed where the node still has the proposal's votes or
+		// transactions, as it may have pruned both
+		status := recordUnknown
+		vote, err := pctx.Stream.GetVote(ctx, event.Proposal.ProposalID, voter.Address)
+		switch {
+		case err != nil:
+			log.Printf("Error checking vote status of %s for closed proposal %s on %s: %v", voter.Label, event.Proposal.ProposalID, pctx.Stream.StateKey, err)
+		case vote != nil:
+			err := h.saveVoteRecord(ctx, pctx, event.Proposal, voter, vote, recordVoted)
+			if err != nil {
+				return fmt.Errorf("error saving vote history: %v", err)
+			}
+			continue
+		default:
+			status, err = h.missingVoteStatus(ctx, pctx, event.Proposal.ProposalID)
+			if err != nil {
+				log.Printf("Error checking the transaction index for proposal %s on %s: %v", event.Proposal.ProposalID, pctx.Stream.StateKey, err)
+			}
+		}
+
+		incident := proposals.Incident{
+			StateKey:          pctx.Stream.StateKey,
+			ChainName:         event.ChainName,
+			ProposalID:        event.Proposal.ProposalID,
+			Title:             event.Proposal.Title,
+			Status:            event.Proposal.Status,
+			VotingEndTime:     event.Proposal.VotingEndTime,
+			Voter:             voter.Address,
+			VoterLabel:        voter.Label,
+			Reminders:         event.Snapshot.Reminders,
+			VoteStatusUnknown: status == recordUnknown,
+			RecordedAt:        h.Now(),
+		}
+		err = h.Services.Store.SaveIncident(ctx, incident)
+		if err != nil {
+			return fmt.Errorf("error saving incident: %v", err)
+		}
+		err = h.saveVoteRecord(ctx, pctx, event.Proposal, voter, nil, status)
+		if err != nil {
+			return fmt.Errorf("error saving vote history: %v", err)
+		}
+		pctx.Incidents[event.Proposal.ProposalID] = append(pctx.Incidents[event.Proposal.ProposalID], incident)
+	}
+	return nil
+}
+
+// closedIncidents returns the incidents recorded for the closed proposal of the event. When the event
+// is retried, they were recorded on an earlier run and are loaded from storage.
+func (h *Handler) closedIncidents(ctx context.Context, pctx *ProcessProposalContext, event events.Event) ([]proposals.Incident, error) {
+	if incidents, recorded := pctx.Incidents[event.Proposal.ProposalID]; recorded {
+		return incidents, nil
+	}
+
+	stored, err := h.Services.Store.GetIncidents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading incidents: %v", err)
+	}
+	var incidents []proposals.Incident
+	for _, incident := range stored {
+		if incident.StateKey == pctx.Stream.StateKey && incident.ProposalID == event.Proposal.ProposalID {
+			incidents = append(incidents, incident)
+		}
+	}
+	pctx.Incidents[event.Proposal.ProposalID] = incidents
+	return incidents, nil
+}
+
+// sendMissedVoteAlert raises a missed vote with high severity, mentioning the configured role. A vote
+// whose status is unknown is raised as unconfirmed instead.
+func sendMissedVoteAlert(pctx *ProcessProposalContext, event events.Event, incident proposals.Incident) error {
+	chain := pctx.Cfg.Chains[event.ChainName]
+	discordNotifier, err := getDiscordNotifier(pctx.Cfg, chain, event.ChainName, pctx.GlobalDiscordNotifier)
+	if err != nil {
+		return err
+	}
+
+	alertDetails, err := generateAlertDetails(pctx.Cfg, chain, event.ChainName, event.Proposal)
+	if err != nil {
+		return err
+	}
+
+	alertType := AlertTypeMissedVote
+	if incident.VoteStatusUnknown {
+		alertType = AlertTypeUnconfirmedVote
+	}
+	messageContent := fmt.Sprintf("**%s %s**: %s\n\n**Proposal title:** %s\n\n**Voter:** %s (%s)\n\n**Result:** %s\n\n",
+		alertType, event.ChainName, event.Proposal.ProposalID, event.Proposal.Title, incident.VoterLabel, incident.Voter, proposals.StatusLabel(event.Proposal.Status))
+	if incident.VoteStatusUnknown {
+		messageContent += "The vote wasn't seen before voting ended and couldn't be confirmed since, so it may have been cast. Check it on chain.\n\n"
+	}
+	messageContent += fmt.Sprintf("%s**Read full proposal details:**\n%s", remindersSection(incident.Reminders), alertDetails.ProposalDetail)
+
+	return sendDiscordEmbed(discordNotifier, pctx.Cfg.Discord.Mention, notifiers.SevereMessageBoxColor, messageContent)
+}
+
+func remindersSection(reminders []proposals.Reminder) string {
+	if len(reminders) == 0 {
+		return "**Reminders sent:** none\n\n"
+	}
+	var lines []string
+	for _, reminder := range reminders {
+		lines = append(lines, fmt.Sprintf("%s at %s", reminder.Alert, reminder.SentAt.UTC().Format("2006-01-02 15:04 UTC")))
+	}
+	return fmt.Sprintf("**Reminders sent:**\n%s\n\n", strings.Join(lines, "\n"))
+}
@@ -2,12 +2,14 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"time"
 
 	"tendermint_proposal_monitor/config"
+	"tendermint_proposal_monitor/events"
 	"tendermint_proposal_monitor/notifiers"
 	"tendermint_proposal_monitor/proposals"
 	"tendermint_proposal_monitor/services"
@@ -15,11 +17,22 @@ import (
 
 type Handler struct {
 	Services *services.NewServices
+	// Bus receives every event emitted during a run, after the alerts and state for it are handled
+	Bus    *events.Bus
+	Engine *events.Engine
+	// Now is the time proposals and nodes are judged against, which replays set to the recording time
+	Now func() time.Time
+
+	// history is the participation history, loaded on first use during the run
+	history []proposals.VoteRecord
 }
 
 func NewHandler(services *services.NewServices) *Handler {
 	return &Handler{
 		Services: services,
+		Bus:      events.NewBus(),
+		Engine:   events.NewEngine(VotingNearingWindow),
+		Now:      time.Now,
 	}
 }
 
@@ -31,12 +44,26 @@ type ProcessProposalContext struct {
 	LastChecked               map[string]int
 	AlertedProposals          map[string]map[string]bool
 	VotingEndAlertedProposals map[string]map[string]bool
+	Snapshots                 map[string]map[string]proposals.ProposalSnapshot
+	EmittedEvents             map[string]map[string]bool
+	Result                    *ChainResult
+	Stream                    proposalStream
+	Params                    *proposals.GovParams
+	// Reminders holds the reminders sent about proposals of the stream during the run, until they are
+	// added to its snapshots
+	Reminders map[string][]proposals.Reminder
+	// VoteTxs holds the vote transactions searched during the run, by proposal ID and voter
+	VoteTxs map[string]*proposals.VoteTx
+	// Incidents holds the missed votes recorded during the run by proposal ID, until they are alerted
+	Incidents map[string][]proposals.Incident
 }
 
 // Define constants for alert types and file names
 const (
 	AlertTypeNewProposal   = "📝 New proposal on"
 	AlertTypeVotingNearing = "🕒 Voting period is nearing its end"
+	// Expedited proposals that fail to pass are converted to regular proposals with a full voting period
+	AlertTypeExpeditedConverted = "🔁 Expedited proposal converted to a regular proposal on"
 )
 
 // Define constant for voting alert behavior
@@ -44,14 +71,24 @@ const (
 	VotingAlertBehaviorOnlyIfNotVoted = "only_if_not_voted"
 )
 
-func (h *Handler) Run(cfg *config.Configurations, useMock bool) error {
-	lastChecked, alertedProposals, votingEndAlertedProposals, err := h.Services.FirestoreHandler.InitState()
+// VotingNearingWindow is how long before the end of the voting period the nearing alert is sent
+const VotingNearingWindow = 24 * time.Hour
+
+// Run checks every configured chain once. Cancelling ctx aborts the chain requests still in flight.
+func (h *Handler) Run(ctx context.Context, cfg *config.Configurations) (*RunResult, error) {
+	lastChecked, alertedProposals, votingEndAlertedProposals, err := h.Services.Store.InitState()
 	if err != nil {
 		log.Printf("error init state: %v", err)
-		return fmt.Errorf("error init state: %v", err)
+		return nil, fmt.Errorf("error init state: %v", err)
 	}
 
-	globalDiscordNotifier := &notifiers.DiscordNotifier{WebhookURL: cfg.Discord.Webhook}
+	snapshots, emittedEvents, err := h.initEventState(ctx, alertedProposals, votingEndAlertedProposals)
+	if err != nil {
+		log.Printf("error init event state: %v", err)
+		return nil, fmt.Errorf("error init event state: %v", err)
+	}
+
+	globalDiscordNotifier := &notifiers.DiscordNotifier{WebhookURL: cfg.Discord.Webhook, HTTPClient: h.Services.AlertHTTPClient}
 
 	proposalCtx := &ProcessProposalContext{
 		Cfg:                       cfg,
@@ -59,113 +96,459 @@ func (h *Handler) Run(cfg *config.Configurations, useMock bool) error {
 		LastChecked:               lastChecked,
 		AlertedProposals:          alertedProposals,
 		VotingEndAlertedProposals: votingEndAlertedProposals,
+		Snapshots:                 snapshots,
+		EmittedEvents:             emittedEvents,
 	}
+	bus := h.newRunBus(proposalCtx)
+	result := NewRunResult()
 
 	log.Printf("Checking for new proposals...")
 
 	for chainName, chain := range cfg.Chains {
-		propList, err := fetchProposals(chain, useMock, chainName)
+		if ctx.Err() != nil {
+			return result, fmt.Errorf("monitor run cancelled: %v", ctx.Err())
+		}
+
+		chainResult := result.Chain(chainName)
+		endpoint, reason, err := h.selectEndpoint(ctx, proposalCtx, chainName, chain)
 		if err != nil {
+			log.Printf("Skipping chain %s: %v", chainName, err)
+			chainResult.Skipped = true
+			chainResult.Reason = err.Error()
 			continue
 		}
+		if reason != "" {
+			log.Printf("Chain %s: %s", chainName, reason)
+		}
+		chain.APIEndpoint = endpoint
+		chainResult.Reason = reason
+		chainResult.Endpoint = chain.APIEndpoint
 
 		proposalCtx.Chain = chain
 		proposalCtx.ChainName = chainName
+		proposalCtx.Result = chainResult
 
-		err = h.processProposals(propList, proposalCtx)
+		voters := h.resolveVoters(ctx, chainName, chain, chainResult)
+		streams, err := h.chainStreams(chainName, chain, voters)
 		if err != nil {
-			log.Printf("Error processing proposals for chain %s: %v", chainName, err)
+			log.Printf("Skipping chain %s: %v", chainName, err)
+			chainResult.Skipped = true
+			chainResult.Reason = err.Error()
+			continue
+		}
+		for _, stream := range streams {
+			propList, err := stream.Fetch(ctx)
+			if err != nil {
+				log.Printf("Error fetching proposals for %s: %v", stream.StateKey, err)
+				chainResult.Errors = append(chainResult.Errors, err.Error())
+				continue
+			}
+			chainResult.Proposals += len(propList)
+
+			proposalCtx.Stream = stream
+			err = h.processProposals(ctx, bus, propList, proposalCtx)
+			if err != nil {
+				log.Printf("Error processing proposals for %s: %v", stream.StateKey, err)
+				chainResult.Errors = append(chainResult.Errors, err.Error())
+			}
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-func (h *Handler) processProposals(propList []proposals.Proposal, pctx *ProcessProposalContext) error {
-	ctx := context.Background()
-	for _, proposal := range propList {
-		if shouldSkipProposal(proposal) {
-			continue
+// initEventState loads the proposal snapshots and the event ledger. Proposals alerted on before the
+// ledger existed are recorded in it so their events aren't emitted a second time.
+func (h *Handler) initEventState(ctx context.Context, alertedProposals, votingEndAlertedProposals map[string]map[string]bool) (map[string]map[string]proposals.ProposalSnapshot, map[string]map[string]bool, error) {
+	snapshots, err := h.Services.Store.GetProposalSnapshots(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading proposal snapshots: %v", err)
+	}
+
+	emittedEvents, err := h.Services.Store.GetEmittedEvents(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading emitted events: %v", err)
+	}
+
+	seed := func(alerted map[string]map[string]bool, eventType events.Type) {
+		for chainName, chainAlerted := range alerted {
+			for proposalID, ok := range chainAlerted {
+				if !ok {
+					continue
+				}
+				event := events.Event{Type: eventType, Proposal: proposals.Proposal{ProposalID: proposalID}}
+				markEmitted(emittedEvents, chainName, event)
+			}
 		}
+	}
+	seed(alertedProposals, events.ProposalSubmitted)
+	seed(votingEndAlertedProposals, events.DeadlineApproaching)
+
+	return snapshots, emittedEvents, nil
+}
 
-		proposalID, err := strconv.Atoi(proposal.ProposalID)
+func (h *Handler) processProposals(ctx context.Context, bus *events.Bus, propList []proposals.Proposal, pctx *ProcessProposalContext) error {
+	stateKey := pctx.Stream.StateKey
+	previous := pctx.Snapshots[stateKey]
+
+	pctx.Params = nil
+	pctx.Reminders = make(map[string][]proposals.Reminder)
+	pctx.VoteTxs = make(map[string]*proposals.VoteTx)
+	pctx.Incidents = make(map[string][]proposals.Incident)
+	if pctx.Stream.GovParams != nil {
+		params, err := pctx.Stream.GovParams(ctx)
 		if err != nil {
-			log.Printf("Invalid proposal ID: %v", err)
+			log.Printf("Error fetching gov params for chain %s: %v", pctx.ChainName, err)
+		}
+		pctx.Params = params
+	}
+
+	state := events.ChainState{
+		ChainName:           pctx.ChainName,
+		Previous:            previous,
+		LastChecked:         pctx.LastChecked[stateKey],
+		Voters:              voterAddresses(pctx.Stream.Voters),
+		Votes:               h.checkVotes(ctx, pctx, propList, previous),
+		DetectCancellations: pctx.Stream.DetectCancellations,
+		Params:              pctx.Params,
+	}
+
+	// Proposals with an event that failed to publish keep their previous snapshot, so the event is
+	// detected again and retried on the next run. Events published for them meanwhile are in the
+	// ledger, so they aren't published twice.
+	unpublished := make(map[string]bool)
+	for _, event := range h.Engine.Diff(state, propList, h.Now()) {
+		if pctx.EmittedEvents[stateKey][event.Key()] {
 			continue
 		}
 
-		// Check if the proposal is new and alert if it hasn't been alerted yet
-		err = h.checkAndSendNewProposalAlert(ctx, pctx, proposal, proposalID)
+		err := bus.Publish(ctx, event)
 		if err != nil {
-			log.Printf("Error checking new proposal alert: %v", err)
+			log.Printf("Error publishing event: %v", err)
+			pctx.Result.Errors = append(pctx.Result.Errors, err.Error())
+			unpublished[event.Proposal.ProposalID] = true
 			continue
 		}
+		pctx.Result.Events++
 
-		// Check if the proposal is nearing its voting end time and if it has not been alerted yet
-		err = h.checkAndSendVotingNearingAlert(ctx, pctx, proposal)
-		if err != nil {
-			log.Printf("Error checking voting nearing alert: %v", err)
+		h.recordEmitted(ctx, pctx, stateKey, event.Key())
+	}
+
+	snapshots := h.Engine.Snapshot(state, propList)
+	for proposalID := range unpublished {
+		if snapshot, known := previous[proposalID]; known {
+			snapshots[proposalID] = snapshot
+		} else {
+			delete(snapshots, proposalID)
+		}
+	}
+	pctx.Snapshots[stateKey] = snapshots
+
+	err := h.backfillVoteHistory(ctx, pctx, propList, unpublished)
+	if err != nil {
+		log.Printf("Error filling in vote history of %s: %v", stateKey, err)
+	}
+
+	for proposalID, reminders := range pctx.Reminders {
+		snapshot, ok := pctx.Snapshots[stateKey][proposalID]
+		if !ok {
 			continue
 		}
+		snapshot.Reminders = append(append([]proposals.Reminder(nil), snapshot.Reminders...), reminders...)
+		pctx.Snapshots[stateKey][proposalID] = snapshot
+	}
+	err = h.Services.Store.SaveProposalSnapshots(ctx, pctx.Snapshots)
+	if err != nil {
+		return fmt.Errorf("error saving proposal snapshots: %v", err)
 	}
 	return nil
 }
 
-func (h *Handler) checkAndSendNewProposalAlert(ctx context.Context, pctx *ProcessProposalContext, proposal proposals.Proposal, proposalID int) error {
-	if proposalID > pctx.LastChecked[pctx.ChainName] {
-		err := SendDiscordAlert(pctx.Cfg, pctx.Chain, pctx.ChainName, proposal, pctx.GlobalDiscordNotifier, AlertTypeNewProposal)
-		if err != nil {
-			return fmt.Errorf("error sending alert for new proposal: %v", err)
+// checkVotes returns how each voter voted on the proposals in their voting period, nil for those it
+// hasn't voted on, leaving out votes whose status isn't known. Where the stream can search vote
+// transactions, the votes of each voter are found with one search per run, which also catches votes
+// changed since. Votes are only looked up by proposal when that search failed or was cut short, except
+// for votes already confirmed in the snapshots, which are never looked up again.
+func (h *Handler) checkVotes(ctx context.Context, pctx *ProcessProposalContext, propList []proposals.Proposal, previous map[string]proposals.ProposalSnapshot) map[string]map[string]*proposals.Vote {
+	votes := make(map[string]map[string]*proposals.Vote)
+	batched := h.searchVoterVotes(ctx, pctx, propList)
+	for _, proposal := range propList {
+		if proposal.Status != proposals.ProposalStatusVotingPeriod {
+			continue
+		}
+
+		snapshot, known := previous[proposal.ProposalID]
+		proposalVotes := make(map[string]*proposals.Vote)
+		for _, voter := range pctx.Stream.Voters {
+			var confirmed *proposals.Vote
+			if known && snapshot.VotedBy != nil && snapshot.HasVoted(voter.Address) {
+				confirmed = snapshot.VoteOf(voter.Address)
+			}
+
+			// A vote transaction found by the search is the latest vote
+			voteTxs, searched := batched[voter.Address]
+			if searched && voteTxs.Txs[proposal.ProposalID] != nil {
+				proposalVotes[voter.Address] = voteTxs.Txs[proposal.ProposalID].Vote
+				continue
+			}
+			if confirmed != nil {
+				proposalVotes[voter.Address] = confirmed
+				continue
+			}
+			if searched && voteTxs.Complete {
+				proposalVotes[voter.Address] = nil
+				continue
+			}
+
+			vote, err := pctx.Stream.GetVote(ctx, proposal.ProposalID, voter.Address)
+			if errors.Is(err, proposals.ErrVoteUnconfirmed) {
+				// Votes stay in state while voting is open, so the votes endpoint not knowing it is enough
+				proposalVotes[voter.Address] = nil
+				continue
+			}
+			if err != nil {
+				// The vote status stays unknown. Unsupported lookups won't recover by themselves, so
+				// they are reported in the run result as well.
+				log.Printf("Error checking vote status of %s for proposal %s on %s: %v", voter.Label, proposal.ProposalID, pctx.Stream.StateKey, err)
+				if errors.Is(err, proposals.ErrVoteStatusUnsupported) {
+					pctx.Result.Errors = append(pctx.Result.Errors, fmt.Sprintf("%s: %v", voter.Label, err))
+				}
+				continue
+			}
+			proposalVotes[voter.Address] = vote
+		}
+		votes[proposal.ProposalID] = proposalVotes
+	}
+	return votes
+}
+
+// searchVoterVotes searches the vote transactions of every voter of the stream once, back to the start
+// of the oldest open voting period, keeping them for the vote alerts and history of the run as well.
+// Voters whose search fails are left out.
+func (h *Handler) searchVoterVotes(ctx context.Context, pctx *ProcessProposalContext, propList []proposals.Proposal) map[string]*proposals.VoterVoteTxs {
+	batched := make(map[string]*proposals.VoterVoteTxs)
+	if pctx.Stream.VoterVoteTxs == nil {
+		return batched
+	}
+
+	var since time.Time
+	open := false
+	for _, proposal := range propList {
+		if proposal.Status != proposals.ProposalStatusVotingPeriod {
+			continue
 		}
-		pctx.LastChecked[pctx.ChainName] = proposalID
-		if pctx.AlertedProposals[pctx.ChainName] == nil {
-			pctx.AlertedProposals[pctx.ChainName] = make(map[string]bool)
+		// Without a start, the search isn't cut short at all
+		start, _ := time.Parse(time.RFC3339, proposal.VotingStartTime)
+		if !open || start.Before(since) {
+			since = start
 		}
-		pctx.AlertedProposals[pctx.ChainName][proposal.ProposalID] = true
+		open = true
+	}
+	if !open {
+		return batched
+	}
 
-		err = h.saveState(ctx, pctx)
+	for _, voter := range pctx.Stream.Voters {
+		voteTxs, err := pctx.Stream.VoterVoteTxs(ctx, voter.Address, since)
 		if err != nil {
-			return fmt.Errorf("error saving state: %v", err)
+			log.Printf("Error searching votes of %s on %s, looking them up by proposal: %v", voter.Label, pctx.Stream.StateKey, err)
+			continue
+		}
+		batched[voter.Address] = voteTxs
+		for proposalID, tx := range voteTxs.Txs {
+			pctx.VoteTxs[proposalID+"/"+voter.Address] = tx
 		}
 	}
-	return nil
+	return batched
+}
+
+// newRunBus wires the alert and state subscribers of a run in front of the handler's own bus
+func (h *Handler) newRunBus(pctx *ProcessProposalContext) *events.Bus {
+	bus := events.NewBus()
+	// Subscribers that send alerts or query the chain are named, so an event retried after a later
+	// subscriber failed doesn't repeat their work. The others only save state from the event itself,
+	// which is safe to repeat.
+	bus.Ledger = deliveryLedger{h: h, pctx: pctx}
+	// Votes are recorded before any alert is sent, so a failing alert doesn't lose the history
+	bus.SubscribeAs("closed_votes", events.SubscriberFunc(func(ctx context.Context, event events.Event) error {
+		return h.recordClosedVotes(ctx, pctx, event)
+	}), events.ProposalClosed)
+	bus.SubscribeAs("alert", events.SubscriberFunc(func(ctx context.Context, event events.Event) error {
+		return h.sendEventAlert(ctx, pctx, event)
+	}), events.ProposalSubmitted, events.DeadlineApproaching, events.VoteDetected, events.VoteChanged, events.ExpeditedConverted, events.UpgradeApproaching, events.ProposalClosed)
+	bus.SubscribeAs("upgrade_readiness", events.SubscriberFunc(func(ctx context.Context, event events.Event) error {
+		return h.checkUpgradeReadiness(ctx, pctx, event)
+	}), events.UpgradeApproaching)
+	bus.Subscribe(events.SubscriberFunc(func(ctx context.Context, event events.Event) error {
+		return h.recordEventState(ctx, pctx, event)
+	}), events.ProposalSubmitted, events.DeadlineApproaching)
+	bus.Subscribe(events.SubscriberFunc(func(ctx context.Context, event events.Event) error {
+		return h.recordVoteHistory(ctx, pctx, event)
+	}), events.VoteDetected, events.VoteChanged)
+	bus.SubscribeAs("subscribers", h.Bus)
+	return bus
+}
+
+// deliveryLedger records the deliveries of events to named subscribers in the event ledger of the
+// stream being processed
+type deliveryLedger struct {
+	h    *Handler
+	pctx *ProcessProposalContext
+}
+
+func (l deliveryLedger) Delivered(event events.Event, subscriber string) bool {
+	return l.pctx.EmittedEvents[l.pctx.Stream.StateKey][deliveryKey(event, subscriber)]
+}
+
+func (l deliveryLedger) RecordDelivery(ctx context.Context, event events.Event, subscriber string) {
+	l.h.recordEmitted(ctx, l.pctx, l.pctx.Stream.StateKey, deliveryKey(event, subscriber))
 }
 
-func (h *Handler) checkAndSendVotingNearingAlert(ctx context.Context, pctx *ProcessProposalContext, proposal proposals.Proposal) error {
-	if proposal.Status != proposals.ProposalStatusName[1] {
+// deliverOnce sends one of several alerts of a subscriber for the event, unless it was sent before
+func (l deliveryLedger) deliverOnce(ctx context.Context, event events.Event, name string, send func() error) error {
+	if l.Delivered(event, name) {
 		return nil
 	}
-
-	votingEndTime, err := time.Parse(time.RFC3339, proposal.VotingEndTime)
+	err := send()
 	if err != nil {
-		return fmt.Errorf("error parsing voting end time: %v", err)
+		return err
 	}
+	l.RecordDelivery(ctx, event, name)
+	return nil
+}
+
+func deliveryKey(event events.Event, subscriber string) string {
+	return fmt.Sprintf("%s#%s", event.Key(), subscriber)
+}
+
+func (h *Handler) sendEventAlert(ctx context.Context, pctx *ProcessProposalContext, event events.Event) error {
+	chain := pctx.Cfg.Chains[event.ChainName]
+
+	switch event.Type {
+	case events.ProposalSubmitted:
+		err := SendDiscordAlert(pctx.Cfg, chain, event.ChainName, event.Proposal, pctx.GlobalDiscordNotifier, AlertTypeNewProposal)
+		if err != nil {
+			return fmt.Errorf("error sending alert for new proposal: %v", err)
+		}
+		h.recordReminder(pctx, event)
+
+	case events.DeadlineApproaching:
+		if !shouldSendVotingNearingAlert(pctx.Cfg, pctx.Stream, event) {
+			return nil
+		}
+
+		err := SendDiscordAlert(pctx.Cfg, chain, event.ChainName, event.Proposal, pctx.GlobalDiscordNotifier, AlertTypeVotingNearing, votersSection(pctx.Stream.Voters, event), h.tallySection(ctx, pctx, event.Proposal), peersSection(ctx, pctx, event.Proposal), prepareVoteSection(pctx, event))
+		if err != nil {
+			return fmt.Errorf("error sending alert for voting nearing end: %v", err)
+		}
+		h.recordReminder(pctx, event)
+
+	case events.VoteDetected, events.VoteChanged:
+		err := h.sendVoteAlert(ctx, pctx, event)
+		if err != nil {
+			return fmt.Errorf("error sending alert for vote: %v", err)
+		}
+
+	case events.ExpeditedConverted:
+		err := SendDiscordAlert(pctx.Cfg, chain, event.ChainName, event.Proposal, pctx.GlobalDiscordNotifier, AlertTypeExpeditedConverted)
+		if err != nil {
+			return fmt.Errorf("error sending alert for expedited proposal conversion: %v", err)
+		}
 
-	currentTime := time.Now()
-	if !pctx.VotingEndAlertedProposals[pctx.ChainName][proposal.ProposalID] && votingEndTime.Sub(currentTime) <= 24*time.Hour {
-		shouldSendAlert, err := shouldSendVotingNearingAlert(pctx.Cfg, pctx.Chain, proposal)
+	case events.UpgradeApproaching:
+		err := SendUpgradeAlert(pctx, event, h.Now())
 		if err != nil {
-			return err
+			return fmt.Errorf("error sending alert for approaching upgrade: %v", err)
 		}
 
-		if shouldSendAlert {
-			err = sendVotingNearingAlert(ctx, h, pctx, proposal)
+	case events.ProposalClosed:
+		// Each alert is sent even when another one failed; the event is retried if any did, and each
+		// alert is recorded once sent so the retry only sends those that failed
+		ledger := deliveryLedger{h: h, pctx: pctx}
+		var errs []error
+		err := ledger.deliverOnce(ctx, event, "outcome_alert", func() error {
+			return SendOutcomeAlert(pctx, event)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error sending alert for proposal outcome: %v", err))
+		}
+
+		if event.Proposal.ChangesGovParams && event.Proposal.Status == proposals.ProposalStatusPassed {
+			err := ledger.deliverOnce(ctx, event, "gov_params_alert", func() error {
+				return h.sendGovParamsChangedAlert(ctx, pctx, event)
+			})
 			if err != nil {
-				return fmt.Errorf("error sending alert for voting nearing end: %v", err)
+				errs = append(errs, fmt.Errorf("error sending alert for gov params change: %v", err))
 			}
 		}
+
+		incidents, err := h.closedIncidents(ctx, pctx, event)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, incident := range incidents {
+			incident := incident
+			err := ledger.deliverOnce(ctx, event, "missed_vote_alert/"+incident.Voter, func() error {
+				return sendMissedVoteAlert(pctx, event, incident)
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error sending alert for missed vote: %v", err))
+			}
+		}
+		return errors.Join(errs...)
 	}
+	return nil
+}
+
+// recordEventState keeps the per-alert state documents up to date alongside the event ledger
+func (h *Handler) recordEventState(ctx context.Context, pctx *ProcessProposalContext, event events.Event) error {
+	switch event.Type {
+	case events.ProposalSubmitted:
+		proposalID, err := strconv.Atoi(event.Proposal.ProposalID)
+		if err != nil {
+			return fmt.Errorf("invalid proposal ID: %v", err)
+		}
+		stateKey := pctx.Stream.StateKey
+		if proposalID > pctx.LastChecked[stateKey] {
+			pctx.LastChecked[stateKey] = proposalID
+		}
+		if pctx.AlertedProposals[stateKey] == nil {
+			pctx.AlertedProposals[stateKey] = make(map[string]bool)
+		}
+		pctx.AlertedProposals[stateKey][event.Proposal.ProposalID] = true
 
+		err = h.saveState(ctx, pctx)
+		if err != nil {
+			return fmt.Errorf("error saving state: %v", err)
+		}
+
+	case events.DeadlineApproaching:
+		// The expedited reminder isn't recorded, as that would suppress the reminder that is due
+		// again if the proposal is converted to a regular one
+		if event.Stage == events.StageExpedited {
+			return nil
+		}
+		stateKey := pctx.Stream.StateKey
+		if pctx.VotingEndAlertedProposals[stateKey] == nil {
+			pctx.VotingEndAlertedProposals[stateKey] = make(map[string]bool)
+		}
+		pctx.VotingEndAlertedProposals[stateKey][event.Proposal.ProposalID] = true
+
+		err := h.Services.Store.SaveAlertedProposals(ctx, proposals.CollectionNameVotingEndAlerted, pctx.VotingEndAlertedProposals)
+		if err != nil {
+			log.Printf("Error saving voting end alerted proposals: %v", err)
+		}
+	}
 	return nil
 }
 
 func (h *Handler) saveState(ctx context.Context, pctx *ProcessProposalContext) error {
-	err := h.Services.FirestoreHandler.SaveLastCheckedProposalIDs(ctx, proposals.CollectionNameLastChecked, pctx.LastChecked)
+	err := h.Services.Store.SaveLastCheckedProposalIDs(ctx, proposals.CollectionNameLastChecked, pctx.LastChecked)
 	if err != nil {
 		return fmt.Errorf("error saving last checked proposal ID: %v", err)
 	}
 
-	err = h.Services.FirestoreHandler.SaveAlertedProposals(ctx, proposals.CollectionNameAlertedProposals, pctx.AlertedProposals)
+	err = h.Services.Store.SaveAlertedProposals(ctx, proposals.CollectionNameAlertedProposals, pctx.AlertedProposals)
 	if err != nil {
 		return fmt.Errorf("error saving alerted proposals: %v", err)
 	}
@@ -173,50 +556,32 @@ func (h *Handler) saveState(ctx context.Context, pctx *ProcessProposalContext) e
 	return nil
 }
 
-func shouldSendVotingNearingAlert(cfg *config.Configurations, chain config.ChainConfig, proposal proposals.Proposal) (bool, error) {
-	if cfg.VotingAlertBehaviorNearing == VotingAlertBehaviorOnlyIfNotVoted {
-		voted, err := proposals.CheckValidatorVoted(chain, proposal.ProposalID, chain.ValidatorAddress, chain.APIVersion)
-		if err != nil {
-			return false, err
-		}
-		if voted {
-			return false, nil
-		}
+// shouldSendVotingNearingAlert skips the alert under only_if_not_voted once every voter is known to
+// have voted. Voters whose vote status couldn't be checked aren't assumed to have voted, nor not to
+// have: the alert is sent and marks their status unknown.
+func shouldSendVotingNearingAlert(cfg *config.Configurations, stream proposalStream, event events.Event) bool {
+	if cfg.VotingAlertBehaviorNearing == VotingAlertBehaviorOnlyIfNotVoted && len(stream.Voters) > 0 {
+		notVoted, unknown := pendingVoters(stream.Voters, event)
+		return len(notVoted) > 0 || len(unknown) > 0
 	}
-	return true, nil
+	return true
 }
 
-func sendVotingNearingAlert(ctx context.Context, h *Handler, pctx *ProcessProposalContext, proposal proposals.Proposal) error {
-	err := SendDiscordAlert(pctx.Cfg, pctx.Chain, pctx.ChainName, proposal, pctx.GlobalDiscordNotifier, AlertTypeVotingNearing)
-	if err != nil {
-		return err
-	}
-	if pctx.VotingEndAlertedProposals[pctx.ChainName] == nil {
-		pctx.VotingEndAlertedProposals[pctx.ChainName] = make(map[string]bool)
-	}
-	pctx.VotingEndAlertedProposals[pctx.ChainName][proposal.ProposalID] = true
-
-	err = h.Services.FirestoreHandler.SaveAlertedProposals(ctx, proposals.CollectionNameVotingEndAlerted, pctx.VotingEndAlertedProposals)
-	if err != nil {
-		log.Printf("Error saving voting end alerted proposals: %v", err)
+// recordEmitted adds the key to the event ledger of the stream, saving it on its own
+func (h *Handler) recordEmitted(ctx context.Context, pctx *ProcessProposalContext, stateKey string, key string) {
+	if pctx.EmittedEvents[stateKey] == nil {
+		pctx.EmittedEvents[stateKey] = make(map[string]bool)
 	}
-	return nil
-}
-
-func fetchProposals(chain config.ChainConfig, useMock bool, chainName string) ([]proposals.Proposal, error) {
-	propList, err := proposals.Fetch(chain, chain.APIVersion, useMock)
+	pctx.EmittedEvents[stateKey][key] = true
+	err := h.Services.Store.SaveEmittedEvent(ctx, proposals.EmittedEvent{StateKey: stateKey, Key: key, EmittedAt: h.Now()})
 	if err != nil {
-		log.Printf("Error fetching proposals for chain %s: %v", chainName, err)
+		log.Printf("Error saving emitted event: %v", err)
 	}
-	return propList, err
 }
 
-func shouldSkipProposal(proposal proposals.Proposal) bool {
-	if statusValue, exists := proposals.ProposalStatusValue[proposal.Status]; exists &&
-		(statusValue == proposals.ProposalStatusValue["PROPOSAL_STATUS_PASSED"] ||
-			statusValue == proposals.ProposalStatusValue["PROPOSAL_STATUS_REJECTED"] ||
-			statusValue == proposals.ProposalStatusValue["PROPOSAL_STATUS_FAILED"]) {
-		return true
+func markEmitted(emittedEvents map[string]map[string]bool, chainName string, event events.Event) {
+	if emittedEvents[chainName] == nil {
+		emittedEvents[chainName] = make(map[string]bool)
 	}
-	return false
+	emittedEvents[chainName][event.Key()] = true
 }
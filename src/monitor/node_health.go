@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tendermint_proposal_monitor/config"
+	"tendermint_proposal_monitor/proposals"
+)
+
+// DefaultMaxBlockLag is used when max_block_lag isn't configured
+const DefaultMaxBlockLag = 5 * time.Minute
+
+// AlertTypeChainMisconfigured is sent to the global channel when an endpoint serves another network
+const AlertTypeChainMisconfigured = "⚠️ Chain misconfigured"
+
+// ChainIDMismatchError is returned when an endpoint serves a different network than the configured chain_id
+type ChainIDMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChainIDMismatchError) Error() string {
+	return fmt.Sprintf("endpoint serves network %s, expected chain_id %s", e.Actual, e.Expected)
+}
+
+// selectEndpoint returns the first endpoint of the chain that serves the configured chain_id and
+// whose node is synced and current, so that proposal lists and vote answers from the wrong network
+// or a lagging node don't lead to false alerts. The reason is empty when the configured api_endpoint
+// is healthy, and explains the failover or skip otherwise.
+func (h *Handler) selectEndpoint(ctx context.Context, pctx *ProcessProposalContext, chainName string, chain config.ChainConfig) (string, string, error) {
+	// The checks rely on the node endpoints of the Cosmos SDK REST API
+	if !proposals.IsCosmosSDK(chain) {
+		return chain.APIEndpoint, "", nil
+	}
+
+	maxBlockLag := pctx.Cfg.MaxBlockLag
+	if maxBlockLag == 0 {
+		maxBlockLag = DefaultMaxBlockLag
+	}
+
+	endpoints := append([]string{chain.APIEndpoint}, chain.FallbackEndpoints...)
+	var problems []string
+	for _, endpoint := range endpoints {
+		err := h.verifyChainID(ctx, chain, endpoint)
+		if err == nil {
+			err = h.checkNodeHealth(ctx, endpoint, maxBlockLag)
+		}
+		if err != nil {
+			var mismatch *ChainIDMismatchError
+			if errors.As(err, &mismatch) {
+				h.alertChainMisconfigured(ctx, pctx, chainName, endpoint, mismatch)
+			}
+			problems = append(problems, fmt.Sprintf("%s: %v", endpoint, err))
+			continue
+		}
+
+		if len(problems) > 0 {
+			return endpoint, fmt.Sprintf("failed over to %s (%s)", endpoint, strings.Join(problems, "; ")), nil
+		}
+		return endpoint, "", nil
+	}
+
+	return "", "", fmt.Errorf("no healthy endpoint (%s)", strings.Join(problems, "; "))
+}
+
+func (h *Handler) checkNodeHealth(ctx context.Context, endpoint string, maxBlockLag time.Duration) error {
+	syncing, err := h.Services.ChainClient.Syncing(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("error checking syncing status: %v", err)
+	}
+	if syncing {
+		return fmt.Errorf("node is syncing")
+	}
+
+	block, err := h.Services.ChainClient.LatestBlock(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("error fetching latest block: %v", err)
+	}
+	lag := h.Now().Sub(block.Time)
+	if lag > maxBlockLag {
+		return fmt.Errorf("latest block %d is %s behind", block.Height, lag.Round(time.Second))
+	}
+	return nil
+}
+
+// verifyChainID checks that the endpoint serves the network of the configured chain_id
+func (h *Handler) verifyChainID(ctx context.Context, chain config.ChainConfig, endpoint string) error {
+	if chain.ChainID == "" {
+		return nil
+	}
+
+	nodeInfo, err := h.Services.ChainClient.NodeInfo(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("error fetching node info: %v", err)
+	}
+	if nodeInfo.DefaultNodeInfo.Network != chain.ChainID {
+		return &ChainIDMismatchError{Expected: chain.ChainID, Actual: nodeInfo.DefaultNodeInfo.Network}
+	}
+	return nil
+}
+
+// alertChainMisconfigured tells operators about an endpoint serving the wrong network. The alert is
+// recorded in the event ledger so it is sent once per endpoint and network, not on every run.
+func (h *Handler) alertChainMisconfigured(ctx context.Context, pctx *ProcessProposalContext, chainName, endpoint string, mismatch *ChainIDMismatchError) {
+	key := fmt.Sprintf("misconfigured/%s/%s", endpoint, mismatch.Actual)
+	if pctx.EmittedEvents[chainName][key] {
+		return
+	}
+
+	if !pctx.Cfg.Discord.Enabled || pctx.Cfg.Discord.Webhook == "" {
+		log.Printf("No global Discord webhook to report misconfigured chain %s", chainName)
+		return
+	}
+
+	messageContent := fmt.Sprintf("**%s %s**\n\n**Endpoint:** %s\n\n**Configured chain_id:** %s\n\n**Network served:** %s\n\nThe endpoint is not used until the configuration is fixed.",
+		AlertTypeChainMisconfigured, chainName, endpoint, mismatch.Expected, mismatch.Actual)
+	err := sendDiscordMessage(pctx.GlobalDiscordNotifier, messageContent)
+	if err != nil {
+		log.Printf("Error sending misconfiguration alert for chain %s: %v", chainName, err)
+		return
+	}
+
+	h.recordEmitted(ctx, pctx, chainName, key)
+}
@@ -0,0 +1,38 @@
+package monitor
+
+import (
+	"fmt"
+
+	"tendermint_proposal_monitor/events"
+	"tendermint_proposal_monitor/proposals"
+)
+
+const AlertTypeProposalClosed = "🏁 Voting ended on"
+
+// SendOutcomeAlert announces the result of a proposal whose voting period ended, along with how our
+// voters voted on it
+func SendOutcomeAlert(pctx *ProcessProposalContext, event events.Event) error {
+	chain := pctx.Cfg.Chains[event.ChainName]
+	discordNotifier, err := getDiscordNotifier(pctx.Cfg, chain, event.ChainName, pctx.GlobalDiscordNotifier)
+	if err != nil {
+		return err
+	}
+
+	alertDetails, err := generateAlertDetails(pctx.Cfg, chain, event.ChainName, event.Proposal)
+	if err != nil {
+		return err
+	}
+
+	messageContent := fmt.Sprintf("**%s %s**: %s\n\n**Proposal title:** %s\n\n**Result:** %s\n\n",
+		AlertTypeProposalClosed, event.ChainName, event.Proposal.ProposalID, event.Proposal.Title, proposals.StatusLabel(event.Proposal.Status))
+	if len(pctx.Stream.Voters) > 0 {
+		section := votesSection(pctx.Stream.Voters, event.Snapshot)
+		if section == "" {
+			section = "**Our votes:** none recorded\n\n"
+		}
+		messageContent += section
+	}
+	messageContent += fmt.Sprintf("**Read full proposal details:**\n%s", alertDetails.ProposalDetail)
+
+	return sendDiscordMessage(discordNotifier, messageContent)
+}
@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"tendermint_proposal_monitor/events"
+	"tendermint_proposal_monitor/proposals"
+)
+
+const AlertTypeGovParamsChanged = "⚙️ Governance parameters changed on"
+
+// tallySection measures the current tally of a proposal against the gov params of its chain. Without
+// params, as on Namada, the share of the votes cast behind each option is shown instead. It is left out
+// of the alert when the tally can't be fetched.
+func (h *Handler) tallySection(ctx context.Context, pctx *ProcessProposalContext, proposal proposals.Proposal) string {
+	if pctx.Stream.Tally == nil {
+		return ""
+	}
+
+	tally, err := pctx.Stream.Tally(ctx, proposal.ProposalID)
+	if err != nil {
+		log.Printf("Error fetching tally of proposal %s on chain %s: %v", proposal.ProposalID, pctx.ChainName, err)
+		return ""
+	}
+	if pctx.Params == nil {
+		return rawTallySection(tally)
+	}
+	bonded, err := proposals.FetchBondedTokens(ctx, h.Services.ChainClient, pctx.Chain)
+	if err != nil {
+		log.Printf("Error fetching bonded tokens on chain %s: %v", pctx.ChainName, err)
+		return ""
+	}
+
+	outcome := proposals.EvaluateTally(tally, bonded, pctx.Params, proposal.Expedited)
+	projection := "failing"
+	switch {
+	case outcome.WouldPass:
+		projection = "passing"
+	case outcome.Vetoed:
+		projection = "vetoed"
+	case !outcome.QuorumMet:
+		projection = "below quorum"
+	}
+
+	return fmt.Sprintf("**Tally:** turnout %s (quorum %s), yes %s (threshold %s), veto %s (veto threshold %s)\n\n**Projected outcome:** %s\n\n",
+		percent(outcome.Turnout), percent(pctx.Params.Quorum), percent(outcome.YesShare), percent(outcome.Threshold),
+		percent(outcome.VetoShare), percent(pctx.Params.VetoThreshold), projection)
+}
+
+// rawTallySection shows the share of the votes cast behind each option, leaving out veto votes on
+// chains without them
+func rawTallySection(tally *proposals.Tally) string {
+	total := new(big.Int)
+	for _, votes := range []*big.Int{tally.Yes, tally.No, tally.Abstain, tally.NoWithVeto} {
+		if votes != nil {
+			total.Add(total, votes)
+		}
+	}
+	if total.Sign() == 0 {
+		return "**Tally:** no votes yet\n\n"
+	}
+
+	share := func(votes *big.Int) string {
+		if votes == nil {
+			return percent(0)
+		}
+		value, _ := new(big.Rat).SetFrac(votes, total).Float64()
+		return percent(value)
+	}
+	section := fmt.Sprintf("**Tally:** yes %s, no %s, abstain %s", share(tally.Yes), share(tally.No), share(tally.Abstain))
+	if tally.NoWithVeto != nil && tally.NoWithVeto.Sign() > 0 {
+		section += fmt.Sprintf(", veto %s", share(tally.NoWithVeto))
+	}
+	return section + " of the votes cast\n\n"
+}
+
+// sendGovParamsChangedAlert announces a passed proposal changing the gov params, along with the new
+// params, which are fetched again since the cached ones are now outdated
+func (h *Handler) sendGovParamsChangedAlert(ctx context.Context, pctx *ProcessProposalContext, event events.Event) error {
+	h.Services.GovParams.Invalidate(event.ChainName)
+
+	chain := pctx.Cfg.Chains[event.ChainName]
+	discordNotifier, err := getDiscordNotifier(pctx.Cfg, chain, event.ChainName, pctx.GlobalDiscordNotifier)
+	if err != nil {
+		return err
+	}
+
+	messageContent := fmt.Sprintf("**%s %s**\n\n**Proposal:** %s - %s\n\n", AlertTypeGovParamsChanged, event.ChainName, event.Proposal.ProposalID, event.Proposal.Title)
+	params, err := h.Services.GovParams.Get(ctx, event.ChainName, pctx.Chain)
+	if err != nil {
+		log.Printf("Error fetching gov params for chain %s: %v", event.ChainName, err)
+	} else {
+		messageContent += formatGovParams(params)
+	}
+	return sendDiscordMessage(discordNotifier, messageContent)
+}
+
+func formatGovParams(params *proposals.GovParams) string {
+	var deposits []string
+	for _, coin := range params.MinDeposit {
+		deposits = append(deposits, coin.Amount+coin.Denom)
+	}
+
+	content := fmt.Sprintf("**Voting period:** %s\n\n", params.VotingPeriod)
+	if params.ExpeditedVotingPeriod > 0 {
+		content += fmt.Sprintf("**Expedited voting period:** %s\n\n", params.ExpeditedVotingPeriod)
+	}
+	content += fmt.Sprintf("**Min deposit:** %s\n\n**Quorum:** %s\n\n**Threshold:** %s\n\n**Veto threshold:** %s",
+		strings.Join(deposits, ", "), percent(params.Quorum), percent(params.Threshold), percent(params.VetoThreshold))
+	return content
+}
+
+func percent(value float64) string {
+	return fmt.Sprintf("%.1f%%", value*100)
+}
@@ -0,0 +1,111 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+
+	"tendermint_proposal_monitor/proposals"
+)
+
+// ParticipationReport summarizes how one of our voters took part in the governance of a chain, over
+// the proposals whose voting period ended within a date range
+type ParticipationReport struct {
+	ChainName         string               `json:"chain_name"`
+	Voter             string               `json:"voter"`
+	VoterLabel        string               `json:"voter_label"`
+	Proposals         int                  `json:"proposals"`
+	Voted             int                  `json:"voted"`
+	Missed            int                  `json:"missed"`
+	Unknown           int                  `json:"unknown"`
+	ParticipationRate float64              `json:"participation_rate"`
+	MedianTimeToVote  string               `json:"median_time_to_vote,omitempty"`
+	MissedProposals   []ParticipationEntry `json:"missed_proposals"`
+	History           []ParticipationEntry `json:"history"`
+}
+
+// ParticipationEntry is a record of the history, with the timing of the vote
+type ParticipationEntry struct {
+	proposals.VoteRecord
+	TimeToVote     string `json:"time_to_vote,omitempty"`
+	DeadlineMargin string `json:"deadline_margin,omitempty"`
+}
+
+// BuildParticipationReports reports on every voter of the chain, or of every chain if none is given,
+// from the final records of proposals whose voting period ended in [from, to). Either end of the
+// range may be zero to leave it open.
+func BuildParticipationReports(history []proposals.VoteRecord, chainName string, from time.Time, to time.Time) []ParticipationReport {
+	reports := make(map[string]*ParticipationReport)
+	timesToVote := make(map[string][]time.Duration)
+	var keys []string
+
+	for _, record := range history {
+		// Records of proposals still open or closed without a result yet aren't final
+		if (chainName != "" && record.ChainName != chainName) || !proposals.IsFinalStatus(record.Status) {
+			continue
+		}
+		votingEndTime, err := time.Parse(time.RFC3339, record.VotingEndTime)
+		if err != nil || (!from.IsZero() && votingEndTime.Before(from)) || (!to.IsZero() && !votingEndTime.Before(to)) {
+			continue
+		}
+
+		key := record.ChainName + "/" + record.Voter
+		report, found := reports[key]
+		if !found {
+			report = &ParticipationReport{ChainName: record.ChainName, Voter: record.Voter, MissedProposals: []ParticipationEntry{}}
+			reports[key] = report
+			keys = append(keys, key)
+		}
+		report.VoterLabel = record.VoterLabel
+
+		entry := ParticipationEntry{VoteRecord: record}
+		if timeToVote, ok := record.TimeToVote(); ok {
+			entry.TimeToVote = timeToVote.Round(time.Minute).String()
+			timesToVote[key] = append(timesToVote[key], timeToVote)
+		}
+		if margin, ok := record.DeadlineMargin(); ok {
+			entry.DeadlineMargin = margin.Round(time.Minute).String()
+		}
+
+		// Records whose vote status is unknown are listed, but left out of the participation rate
+		if record.VoteStatusUnknown {
+			report.Unknown++
+			report.History = append(report.History, entry)
+			continue
+		}
+		report.Proposals++
+		if record.Missed {
+			report.Missed++
+			report.MissedProposals = append(report.MissedProposals, entry)
+		} else {
+			report.Voted++
+		}
+		report.History = append(report.History, entry)
+	}
+
+	sort.Strings(keys)
+	result := make([]ParticipationReport, 0, len(keys))
+	for _, key := range keys {
+		report := reports[key]
+		if report.Proposals > 0 {
+			report.ParticipationRate = float64(report.Voted) / float64(report.Proposals)
+		}
+		if median, ok := medianDuration(timesToVote[key]); ok {
+			report.MedianTimeToVote = median.Round(time.Minute).String()
+		}
+		result = append(result, *report)
+	}
+	return result
+}
+
+func medianDuration(durations []time.Duration) (time.Duration, bool) {
+	if len(durations) == 0 {
+		return 0, false
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2, true
+	}
+	return sorted[middle], true
+}
@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"tendermint_proposal_monitor/proposals"
+)
+
+// peersSection shows how the validators with the most voting power voted, for the nearing alert: the
+// power behind every option, then the vote of each validator
+func peersSection(ctx context.Context, pctx *ProcessProposalContext, proposal proposals.Proposal) string {
+	if pctx.Stream.PeerVotes == nil {
+		return ""
+	}
+
+	peerVotes, err := pctx.Stream.PeerVotes(ctx, proposal.ProposalID)
+	if err != nil {
+		log.Printf("Error fetching peer votes of proposal %s on chain %s: %v", proposal.ProposalID, pctx.ChainName, err)
+		return ""
+	}
+	if len(peerVotes) == 0 {
+		return ""
+	}
+
+	var summary []string
+	for _, option := range proposals.SummarizePeerVotes(peerVotes) {
+		summary = append(summary, fmt.Sprintf("%s %s (%s)", option.Option, strconv.FormatFloat(option.Validators, 'f', -1, 64), percent(option.PowerShare)))
+	}
+	var validators []string
+	for _, peerVote := range peerVotes {
+		validators = append(validators, fmt.Sprintf("%s: %s", peerVote.Moniker, peerVoteLabel(peerVote)))
+	}
+
+	return fmt.Sprintf("**Top %d validators:** %s\n%s\n\n", len(peerVotes), strings.Join(summary, ", "), strings.Join(validators, " · "))
+}
+
+func peerVoteLabel(peerVote proposals.PeerVote) string {
+	switch {
+	case peerVote.Error != "":
+		return proposals.PeerVoteUnknown
+	case peerVote.Vote == nil:
+		return proposals.PeerNotVoted
+	default:
+		return peerVote.Vote.String()
+	}
+}
@@ -0,0 +1,33 @@
+package monitor
+
+// RunResult summarizes what a monitor run did for every chain
+type RunResult struct {
+	Chains map[string]*ChainResult `json:"chains"`
+}
+
+// ChainResult records the outcome of a run for a single chain
+type ChainResult struct {
+	Endpoint  string `json:"endpoint,omitempty"`
+	Proposals int    `json:"proposals"`
+	Events    int    `json:"events"`
+	// Voters are the addresses whose votes are checked, the account address of any configured valoper
+	Voters  []Voter `json:"voters,omitempty"`
+	Skipped bool    `json:"skipped"`
+	// Reason explains why the chain was skipped or why the endpoint in use was chosen
+	Reason string   `json:"reason,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func NewRunResult() *RunResult {
+	return &RunResult{Chains: make(map[string]*ChainResult)}
+}
+
+// Chain returns the result of the given chain, creating it on first use
+func (r *RunResult) Chain(chainName string) *ChainResult {
+	result, ok := r.Chains[chainName]
+	if !ok {
+		result = &ChainResult{}
+		r.Chains[chainName] = result
+	}
+	return result
+}
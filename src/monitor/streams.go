@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tendermint_proposal_monitor/config"
+	"tendermint_proposal_monitor/proposals"
+)
+
+// proposalStream is one source of proposals on a chain. Each stream keeps its own snapshots, event
+// ledger and last checked proposal ID in storage, since proposal IDs are only unique per source.
+type proposalStream struct {
+	StateKey            string
+	Voters              []Voter
+	DetectCancellations bool
+	Fetch               func(ctx context.Context) ([]proposals.Proposal, error)
+	GetVote             func(ctx context.Context, proposalID string, voter string) (*proposals.Vote, error)
+	// VoteTx finds the latest transaction a vote was cast in, and is only set where transactions
+	// can be searched
+	VoteTx func(ctx context.Context, proposalID string, voter string) (*proposals.VoteTx, error)
+	// VoterVoteTxs finds the latest vote transaction of the voter on every proposal since a time at
+	// once, where transactions can be searched, so votes aren't looked up one proposal at a time
+	VoterVoteTxs func(ctx context.Context, voter string, since time.Time) (*proposals.VoterVoteTxs, error)
+	// TxsIndexed tells whether the transactions of a proposal can all be searched, where votes are
+	// pruned once voting ends and are only found by searching transactions
+	TxsIndexed func(ctx context.Context, proposalID string) (bool, error)
+	// Tally and GovParams are only set for the governance stream of the chain. GovParams is only
+	// available on Cosmos SDK chains, whose tally is measured against them.
+	Tally     func(ctx context.Context, proposalID string) (*proposals.Tally, error)
+	GovParams func(ctx context.Context) (*proposals.GovParams, error)
+	// PeerVotes is only set for the governance stream of Cosmos SDK chains that show how the validators
+	// with the most voting power voted
+	PeerVotes func(ctx context.Context, proposalID string) ([]proposals.PeerVote, error)
+}
+
+// chainStreams returns the governance stream of the chain, keyed by the chain name as before, read
+// from its proposal source and checking the votes of the chain's voters. On Cosmos SDK chains, it is followed by a
+// stream for every configured x/group group or policy and DAO DAO proposal contract.
+func (h *Handler) chainStreams(chainName string, chain config.ChainConfig, voters []Voter) ([]proposalStream, error) {
+	client := h.Services.ChainClient
+
+	source, err := proposals.NewProposalSource(client, chainName, chain)
+	if err != nil {
+		return nil, err
+	}
+	streams := []proposalStream{{
+		StateKey:            chainName,
+		Voters:              voters,
+		DetectCancellations: true,
+		Fetch:               source.ListProposals,
+		GetVote:             source.GetVote,
+		Tally:               source.Tally,
+	}}
+	if !proposals.IsCosmosSDK(chain) {
+		return streams, nil
+	}
+	streams[0].GovParams = func(ctx context.Context) (*proposals.GovParams, error) {
+		return h.Services.GovParams.Get(ctx, chainName, chain)
+	}
+	if chain.PeerValidators > 0 {
+		streams[0].PeerVotes = func(ctx context.Context, proposalID string) ([]proposals.PeerVote, error) {
+			return proposals.FetchPeerVotes(ctx, client, chain, proposalID, chain.PeerValidators)
+		}
+	}
+	streams[0].VoteTx = func(ctx context.Context, proposalID string, voter string) (*proposals.VoteTx, error) {
+		return proposals.FindVoteTx(ctx, client, chain, proposalID, voter)
+	}
+	streams[0].VoterVoteTxs = func(ctx context.Context, voter string, since time.Time) (*proposals.VoterVoteTxs, error) {
+		return proposals.FindVoterVoteTxs(ctx, client, chain, voter, since)
+	}
+	streams[0].TxsIndexed = func(ctx context.Context, proposalID string) (bool, error) {
+		return proposals.ProposalTxsIndexed(ctx, client, chain, proposalID)
+	}
+
+	for _, group := range chain.Groups {
+		group := group
+		target := group.PolicyAddress
+		if target == "" {
+			target = group.GroupID
+		}
+		streams = append(streams, proposalStream{
+			StateKey: fmt.Sprintf("%s/%s/%s", chainName, proposals.SourceGroup, target),
+			Voters:   memberVoters(GroupMemberLabel, group.MemberAddress),
+			Fetch: func(ctx context.Context) ([]proposals.Proposal, error) {
+				return proposals.FetchGroupProposals(ctx, client, chain, group)
+			},
+			GetVote: func(ctx context.Context, proposalID string, voter string) (*proposals.Vote, error) {
+				return proposals.FetchGroupMemberVote(ctx, client, chain, proposalID, voter)
+			},
+		})
+	}
+
+	for _, dao := range chain.DAOs {
+		dao := dao
+		streams = append(streams, proposalStream{
+			StateKey: fmt.Sprintf("%s/%s/%s", chainName, proposals.SourceDAO, dao.ContractAddress),
+			Voters:   memberVoters(DAOMemberLabel, dao.VoterAddress),
+			Fetch: func(ctx context.Context) ([]proposals.Proposal, error) {
+				return proposals.FetchDAOProposals(ctx, client, chain, dao)
+			},
+			GetVote: func(ctx context.Context, proposalID string, voter string) (*proposals.Vote, error) {
+				return proposals.FetchDAOMemberVote(ctx, client, chain, dao, proposalID, voter)
+			},
+		})
+	}
+
+	return streams, nil
+}
+
+// Labels of the member whose votes are checked on group and DAO proposals
+const (
+	GroupMemberLabel = "Group member"
+	DAOMemberLabel   = "DAO member"
+)
+
+func memberVoters(label string, address string) []Voter {
+	if address == "" {
+		return nil
+	}
+	return []Voter{{Label: label, Address: address}}
+}
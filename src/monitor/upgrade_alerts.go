@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tendermint_proposal_monitor/events"
+	"tendermint_proposal_monitor/proposals"
+	"tendermint_proposal_monitor/utils"
+)
+
+const (
+	AlertTypeUpgradeApproaching = "⏫ Software upgrade approaching on"
+	AlertTypeUpgradeNotReady    = "🚨 Node not ready for upgrade on"
+)
+
+// SendUpgradeAlert sends the countdown alert of a scheduled software upgrade, counting down from now
+func SendUpgradeAlert(pctx *ProcessProposalContext, event events.Event, now time.Time) error {
+	chain := pctx.Cfg.Chains[event.ChainName]
+	discordNotifier, err := getDiscordNotifier(pctx.Cfg, chain, event.ChainName, pctx.GlobalDiscordNotifier)
+	if err != nil {
+		return err
+	}
+
+	alertDetails, err := generateAlertDetails(pctx.Cfg, chain, event.ChainName, event.Proposal)
+	if err != nil {
+		return err
+	}
+
+	upgrade := event.Proposal.Upgrade
+	estimatedTime, err := time.Parse(time.RFC3339, upgrade.EstimatedTime)
+	if err != nil {
+		return fmt.Errorf("error parsing estimated upgrade time: %v", err)
+	}
+
+	messageContent := fmt.Sprintf("**%s %s** (%s)\n\n**Upgrade name:** %s\n\n**Upgrade height:** %d\n\n**Estimated time:** %s UTC\n\n**Time left: %s**\n\n**Proposal:** %s - %s\n\n%s**Read full proposal details:**\n%s",
+		AlertTypeUpgradeApproaching, event.ChainName, event.Stage, upgrade.Name, upgrade.Height, estimatedTime.UTC().Format("2006-01-02 15:04"),
+		utils.FormatCountdown(estimatedTime, now), event.Proposal.ProposalID, event.Proposal.Title, formatUpgradeInfo(upgrade.ParsedInfo), alertDetails.ProposalDetail)
+
+	return sendDiscordMessage(discordNotifier, messageContent)
+}
+
+// checkUpgradeReadiness alerts when our own node doesn't report the version expected by an approaching
+// upgrade. It runs at every countdown stage and never fails the event, so a readiness problem doesn't
+// cause the countdown alert to be sent again.
+func (h *Handler) checkUpgradeReadiness(ctx context.Context, pctx *ProcessProposalContext, event events.Event) error {
+	chain := pctx.Cfg.Chains[event.ChainName]
+	if chain.OwnNodeEndpoint == "" {
+		return nil
+	}
+
+	upgrade := event.Proposal.Upgrade
+	expectedVersion := chain.UpgradeVersions[upgrade.Name]
+	nodeInfo, err := h.Services.ChainClient.NodeInfo(ctx, chain.OwnNodeEndpoint)
+	if err != nil {
+		log.Printf("Error fetching node info of own node for chain %s: %v", event.ChainName, err)
+		return nil
+	}
+
+	nodeVersion := nodeInfo.ApplicationVersion.Version
+	if proposals.UpgradeVersionMatches(*upgrade, nodeVersion, expectedVersion) {
+		return nil
+	}
+
+	discordNotifier, err := getDiscordNotifier(pctx.Cfg, chain, event.ChainName, pctx.GlobalDiscordNotifier)
+	if err != nil {
+		log.Printf("Error sending upgrade readiness alert: %v", err)
+		return nil
+	}
+
+	expected := expectedVersion
+	if expected == "" {
+		expected = upgrade.Name
+	}
+	messageContent := fmt.Sprintf("**%s %s** (%s)\n\n**Upgrade name:** %s\n\n**Upgrade height:** %d\n\n**Expected version:** %s\n\n**Own node version:** %s\n\nMake sure the upgraded binary is installed before the upgrade height is reached.",
+		AlertTypeUpgradeNotReady, event.ChainName, event.Stage, upgrade.Name, upgrade.Height, expected, nodeVersion)
+	err = sendDiscordMessage(discordNotifier, messageContent)
+	if err != nil {
+		log.Printf("Error sending upgrade readiness alert: %v", err)
+	}
+	return nil
+}
+
+// formatUpgradeInfo lists the binaries of the platforms operators run, with their checksums, and
+// flags any problem with the plan info. It returns an empty string when the info wasn't parsed.
+func formatUpgradeInfo(info *proposals.UpgradeInfo) string {
+	if info == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, platform := range proposals.UpgradeInfoPlatforms {
+		binary, ok := info.Binary(platform)
+		if !ok {
+			continue
+		}
+		checksum := binary.Checksum
+		if checksum == "" {
+			checksum = "-"
+		}
+		fmt.Fprintf(&b, "**Binary %s:** %s\n**Checksum:** %s\n\n", platform, binary.URL, checksum)
+	}
+	if info.SourceURL != "" {
+		fmt.Fprintf(&b, "**Binaries listed at:** %s\n\n", info.SourceURL)
+	}
+	if len(info.Problems) > 0 {
+		b.WriteString("**⚠️ Upgrade info problems:**\n")
+		for _, problem := range info.Problems {
+			fmt.Fprintf(&b, "- %s\n", problem)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
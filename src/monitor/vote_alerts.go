@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"tendermint_proposal_monitor/events"
+	"tendermint_proposal_monitor/proposals"
+)
+
+const (
+	AlertTypeVoteCast    = "✅ Vote cast on"
+	AlertTypeVoteChanged = "🔄 Vote changed on"
+)
+
+// sendVoteAlert confirms that one of our voters voted on a proposal, or changed its vote, with the
+// transaction the vote was cast in when it can be found
+func (h *Handler) sendVoteAlert(ctx context.Context, pctx *ProcessProposalContext, event events.Event) error {
+	chain := pctx.Cfg.Chains[event.ChainName]
+	discordNotifier, err := getDiscordNotifier(pctx.Cfg, chain, event.ChainName, pctx.GlobalDiscordNotifier)
+	if err != nil {
+		return err
+	}
+
+	alertDetails, err := generateAlertDetails(pctx.Cfg, chain, event.ChainName, event.Proposal)
+	if err != nil {
+		return err
+	}
+
+	alertType := AlertTypeVoteCast
+	vote := event.Vote.String()
+	if event.Type == events.VoteChanged {
+		alertType = AlertTypeVoteChanged
+		if event.Previous != nil {
+			vote = fmt.Sprintf("%s (was %s)", vote, event.Previous.VoteOf(event.Voter).String())
+		}
+	}
+
+	messageContent := fmt.Sprintf("**%s %s**: %s\n\n**Proposal title:** %s\n\n**Voter:** %s\n\n**Vote:** %s\n\n",
+		alertType, event.ChainName, event.Proposal.ProposalID, event.Proposal.Title, formatVoters([]Voter{voterOf(pctx.Stream.Voters, event.Voter)}), vote)
+	if event.Vote != nil && event.Vote.Metadata != "" {
+		messageContent += fmt.Sprintf("**Metadata:** %s\n\n", event.Vote.Metadata)
+	}
+
+	// The transaction is only a detail of the alert, so the confirmation is sent without it when the
+	// search fails
+	tx, err := h.findVoteTx(ctx, pctx, event.Proposal.ProposalID, event.Voter)
+	if err != nil {
+		log.Printf("Error searching vote transaction of %s for proposal %s on %s: %v", event.Voter, event.Proposal.ProposalID, pctx.Stream.StateKey, err)
+	} else if tx != nil {
+		messageContent += fmt.Sprintf("**Transaction:** %s (height %s)\n\n", tx.TxHash, tx.Height)
+	}
+
+	messageContent += fmt.Sprintf("**Time left:** %s\n\n**Read full proposal details:**\n%s", alertDetails.TimeLeft, alertDetails.ProposalDetail)
+
+	return sendDiscordMessage(discordNotifier, messageContent)
+}
+
+// prepareVoteSection links the voters that haven't voted yet to unsigned vote transactions prepared
+// by the monitor, for the nearing alert. It needs the public URL of the monitor, and is only shown
+// for the gov proposals of Cosmos SDK chains.
+func prepareVoteSection(pctx *ProcessProposalContext, event events.Event) string {
+	if pctx.Cfg.MonitorURL == "" || pctx.Stream.StateKey != pctx.ChainName || !proposals.IsCosmosSDK(pctx.Chain) {
+		return ""
+	}
+	notVoted, _ := pendingVoters(pctx.Stream.Voters, event)
+	if len(notVoted) == 0 {
+		return ""
+	}
+
+	baseURL := strings.TrimSuffix(pctx.Cfg.MonitorURL, "/") + "/vote-tx?"
+	var lines []string
+	for _, voter := range notVoted {
+		var links []string
+		for _, option := range []string{"yes", "no", "abstain", "no_with_veto"} {
+			query := url.Values{"chain": {event.ChainName}, "proposal": {event.Proposal.ProposalID}, "voter": {voter.Address}, "option": {option}}
+			links = append(links, fmt.Sprintf("[%s](%s%s)", strings.ReplaceAll(option, "_", " "), baseURL, query.Encode()))
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", voter.Label, strings.Join(links, " · ")))
+	}
+	return fmt.Sprintf("**Prepare an unsigned vote:**\n%s\n\n", strings.Join(lines, "\n"))
+}
@@ -0,0 +1,127 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"tendermint_proposal_monitor/config"
+	"tendermint_proposal_monitor/events"
+	"tendermint_proposal_monitor/proposals"
+)
+
+// Voter is one of our addresses whose votes are checked
+type Voter struct {
+	Label   string `json:"label"`
+	Address string `json:"address"`
+}
+
+// resolveVoters returns the voters of the chain, with the addresses their votes are checked under
+func (h *Handler) resolveVoters(ctx context.Context, chainName string, chain config.ChainConfig, result *ChainResult) []Voter {
+	var voters []Voter
+	for _, voter := range chain.VoterList() {
+		voters = append(voters, Voter{Label: voter.Label, Address: h.resolveVoter(ctx, chainName, chain, voter.Address, result)})
+	}
+	result.Voters = voters
+	return voters
+}
+
+// resolveVoter returns the address whose votes are checked for a configured address. Validators vote
+// from their account address, so a valoper address is converted to it, once the validator is found
+// in the staking module. Problems are reported in the chain result without stopping the run, as the
+// derived address is still the right one to check.
+func (h *Handler) resolveVoter(ctx context.Context, chainName string, chain config.ChainConfig, address string, result *ChainResult) string {
+	if !proposals.IsCosmosSDK(chain) || !proposals.IsValoperAddress(address) {
+		return address
+	}
+
+	err := proposals.CheckValidatorExists(ctx, h.Services.ChainClient, chain, address)
+	if err != nil {
+		log.Printf("Chain %s: %v", chainName, err)
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	account, err := proposals.AccountAddress(address, chain.AccountPrefix)
+	if err != nil {
+		log.Printf("Chain %s: error converting %s to an account address: %v", chainName, address, err)
+		result.Errors = append(result.Errors, err.Error())
+		return address
+	}
+
+	log.Printf("Chain %s: checking votes of account %s for validator %s", chainName, account, address)
+	return account
+}
+
+func voterAddresses(voters []Voter) []string {
+	var addresses []string
+	for _, voter := range voters {
+		addresses = append(addresses, voter.Address)
+	}
+	return addresses
+}
+
+// pendingVoters returns the voters known not to have voted, and those whose vote status is unknown
+func pendingVoters(voters []Voter, event events.Event) ([]Voter, []Voter) {
+	var notVoted, unknown []Voter
+	for _, voter := range voters {
+		voted, known := event.Votes[voter.Address]
+		if !known {
+			unknown = append(unknown, voter)
+		} else if !voted {
+			notVoted = append(notVoted, voter)
+		}
+	}
+	return notVoted, unknown
+}
+
+// votersSection lists how our voters voted on the proposal, and which of them still have to, for the
+// nearing alert
+func votersSection(voters []Voter, event events.Event) string {
+	notVoted, unknown := pendingVoters(voters, event)
+	section := votesSection(voters, event.Snapshot)
+	if len(notVoted) > 0 {
+		section += fmt.Sprintf("**Not voted yet:** %s\n\n", formatVoters(notVoted))
+	}
+	if len(unknown) > 0 {
+		section += fmt.Sprintf("**Vote status unknown:** %s\n\n", formatVoters(unknown))
+	}
+	return section
+}
+
+func formatVoters(voters []Voter) string {
+	var formatted []string
+	for _, voter := range voters {
+		formatted = append(formatted, fmt.Sprintf("%s (%s)", voter.Label, voter.Address))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// votesSection shows how each of our voters that voted on the proposal voted
+func votesSection(voters []Voter, snapshot proposals.ProposalSnapshot) string {
+	var lines []string
+	for _, voter := range voters {
+		if !snapshot.HasVoted(voter.Address) {
+			continue
+		}
+		line := fmt.Sprintf("%s (%s): %s", voter.Label, voter.Address, snapshot.VoteOf(voter.Address).String())
+		if vote := snapshot.VoteOf(voter.Address); vote != nil && vote.Metadata != "" {
+			line += fmt.Sprintf(" — %s", vote.Metadata)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("**Our votes:**\n%s\n\n", strings.Join(lines, "\n"))
+}
+
+// voterOf returns the voter with the address, or an unlabelled voter if it isn't one of ours
+func voterOf(voters []Voter, address string) Voter {
+	for _, voter := range voters {
+		if voter.Address == address {
+			return voter
+		}
+	}
+	return Voter{Label: "Voter", Address: address}
+}
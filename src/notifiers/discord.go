@@ -3,11 +3,16 @@ package notifiers
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 )
 
 var MessageBoxColor = 0x00ffff
 
+// SevereMessageBoxColor marks alerts that need action
+var SevereMessageBoxColor = 0xff0000
+
 type DiscordEmbed struct {
 	Color       int    `json:"color"`
 	Description string `json:"description"`
@@ -21,6 +26,8 @@ type DiscordMessage struct {
 
 type DiscordNotifier struct {
 	WebhookURL string
+	// HTTPClient posts to the webhook, the default client when nil
+	HTTPClient *http.Client
 }
 
 func (dn *DiscordNotifier) SendPayload(payload []byte) (*http.Response, error) {
@@ -30,7 +37,10 @@ func (dn *DiscordNotifier) SendPayload(payload []byte) (*http.Response, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
+	client := dn.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
 	return client.Do(req)
 }
 
@@ -48,3 +58,26 @@ func (dn *DiscordNotifier) SendAlert(message string) error {
 
 	return nil
 }
+
+// LogTransport stands in for Discord in mock runs: it logs every message posted to a webhook and
+// answers as Discord does, without sending anything
+type LogTransport struct{}
+
+func (LogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	payload, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Mock alert, not sent to Discord: %s", payload)
+	return &http.Response{
+		Status:     "204 No Content",
+		StatusCode: http.StatusNoContent,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package proposals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+	"tendermint_proposal_monitor/utils"
+)
+
+// valoperSuffix ends the address prefix of validator operators, such as cosmosvaloper
+const valoperSuffix = "valoper"
+
+// IsValoperAddress reports whether the address is a validator operator address
+func IsValoperAddress(address string) bool {
+	hrp, _, err := utils.DecodeBech32(address)
+	return err == nil && strings.HasSuffix(hrp, valoperSuffix) && hrp != valoperSuffix
+}
+
+// AccountAddress returns the account address a validator votes from, which shares its bytes with the
+// operator address. The account prefix defaults to the operator prefix without "valoper".
+func AccountAddress(valoperAddress string, accountPrefix string) (string, error) {
+	hrp, data, err := utils.DecodeBech32(valoperAddress)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(hrp, valoperSuffix) {
+		return "", fmt.Errorf("%s is not a validator operator address", valoperAddress)
+	}
+
+	if accountPrefix == "" {
+		accountPrefix = strings.TrimSuffix(hrp, valoperSuffix)
+	}
+	return utils.EncodeBech32(accountPrefix, data)
+}
+
+// CheckValidatorExists looks the validator operator address up in the staking module
+func CheckValidatorExists(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, valoperAddress string) error {
+	var resp struct {
+		Validator struct {
+			OperatorAddress string `json:"operator_address"`
+		} `json:"validator"`
+	}
+	err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/staking/v1beta1/validators/%s", chain.APIEndpoint, valoperAddress), &resp)
+	var statusErr *chainclient.StatusError
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusBadRequest) {
+		return fmt.Errorf("validator %s not found in the staking module", valoperAddress)
+	}
+	if err != nil {
+		return fmt.Errorf("error looking up validator %s: %v", valoperAddress, err)
+	}
+	return nil
+}
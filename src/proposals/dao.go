@@ -0,0 +1,214 @@
+package proposals
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// SourceDAO marks proposals of DAO DAO proposal module contracts
+const SourceDAO = "dao"
+
+// BlockTimeWindow is the number of recent blocks used to estimate when a block height is reached
+const BlockTimeWindow = 1000
+
+// DAOProposal represents a proposal returned by dao-proposal-single and dao-proposal-multiple contracts
+type DAOProposal struct {
+	ID       uint64 `json:"id"`
+	Proposal struct {
+		Title       string          `json:"title"`
+		Description string          `json:"description"`
+		StartHeight int64           `json:"start_height"`
+		Expiration  json.RawMessage `json:"expiration"`
+		Status      json.RawMessage `json:"status"`
+	} `json:"proposal"`
+}
+
+// daoExpiration is the cw-utils Expiration, of which exactly one field is set
+type daoExpiration struct {
+	AtHeight *int64  `json:"at_height"`
+	AtTime   *string `json:"at_time"`
+}
+
+// daoStatuses maps DAO DAO proposal statuses onto the gov statuses used by the monitor
+var daoStatuses = map[string]string{
+	"open":             ProposalStatusVotingPeriod,
+	"passed":           ProposalStatusPassed,
+	"executed":         ProposalStatusPassed,
+	"rejected":         ProposalStatusRejected,
+	"closed":           ProposalStatusFailed,
+	"execution_failed": ProposalStatusFailed,
+	"veto_timelock":    ProposalStatusPassed,
+	"vetoed":           ProposalStatusRejected,
+}
+
+// querySmart runs a smart query against a contract through the LCD wasm endpoint
+func querySmart(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, contract string, query interface{}, out interface{}) error {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	url := fmt.Sprintf("%s/cosmwasm/wasm/v1/contract/%s/smart/%s", chain.APIEndpoint, contract, base64.URLEncoding.EncodeToString(queryJSON))
+	err = client.GetJSON(ctx, url, &resp)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// FetchDAOProposals returns the most recent proposals of a DAO DAO proposal module. The newest page is
+// read with reverse_proposals, the descending form of list_proposals, like the x/gov proposals are.
+func FetchDAOProposals(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, dao config.DAOConfig) ([]Proposal, error) {
+	var result struct {
+		Proposals []DAOProposal `json:"proposals"`
+	}
+	query := map[string]interface{}{"reverse_proposals": map[string]interface{}{"limit": 100}}
+	err := querySmart(ctx, client, chain, dao.ContractAddress, query, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch proposals of contract %s: %v", dao.ContractAddress, err)
+	}
+	if len(result.Proposals) == 0 {
+		return nil, nil
+	}
+
+	latest, averageBlockTime, err := client.AverageBlockTime(ctx, chain.APIEndpoint, BlockTimeWindow)
+	if err != nil {
+		return nil, fmt.Errorf("error estimating block time: %v", err)
+	}
+
+	var mapped []Proposal
+	for _, p := range result.Proposals {
+		votingStartTime := chainclient.EstimateTimeAt(latest, averageBlockTime, p.Proposal.StartHeight)
+		votingEndTime, expires, err := daoExpirationTime(p.Proposal.Expiration, latest, averageBlockTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiration of proposal %d: %v", p.ID, err)
+		}
+		if !expires {
+			continue
+		}
+
+		title := p.Proposal.Title
+		if title == "" {
+			title = "No Title"
+		}
+		description := p.Proposal.Description
+		if description == "" {
+			description = "No Description"
+		}
+
+		detailURL := "-"
+		if dao.DetailURL != "" {
+			detailURL = fmt.Sprintf("%s/%d", dao.DetailURL, p.ID)
+		}
+
+		mapped = append(mapped, Proposal{
+			ProposalID:      strconv.FormatUint(p.ID, 10),
+			Status:          daoStatus(p.Proposal.Status),
+			Title:           title,
+			Description:     description,
+			VotingStartTime: votingStartTime.UTC().Format(time.RFC3339),
+			VotingEndTime:   votingEndTime.UTC().Format(time.RFC3339),
+			Source:          SourceDAO,
+			DetailURL:       detailURL,
+		})
+	}
+	return mapped, nil
+}
+
+// daoStatus reads the status, which is a plain string except for variants carrying data
+// such as {"veto_timelock": {...}}
+func daoStatus(raw json.RawMessage) string {
+	var name string
+	if json.Unmarshal(raw, &name) != nil {
+		var variant map[string]json.RawMessage
+		if json.Unmarshal(raw, &variant) != nil {
+			return ProposalStatusUnspecified
+		}
+		for key := range variant {
+			name = key
+		}
+	}
+
+	status, ok := daoStatuses[name]
+	if !ok {
+		return ProposalStatusUnspecified
+	}
+	return status
+}
+
+// daoExpirationTime returns when voting on the proposal ends, and false for proposals that never expire
+func daoExpirationTime(raw json.RawMessage, latest *chainclient.Block, averageBlockTime time.Duration) (time.Time, bool, error) {
+	var expiration daoExpiration
+	err := json.Unmarshal(raw, &expiration)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	switch {
+	case expiration.AtTime != nil:
+		nanos, err := strconv.ParseInt(*expiration.AtTime, 10, 64)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return time.Unix(0, nanos), true, nil
+	case expiration.AtHeight != nil:
+		return chainclient.EstimateTimeAt(latest, averageBlockTime, *expiration.AtHeight), true, nil
+	default:
+		return time.Time{}, false, nil
+	}
+}
+
+// FetchDAOMemberVote returns the vote of the member on the proposal, or nil if it hasn't voted
+func FetchDAOMemberVote(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, dao config.DAOConfig, proposalID string, voterAddress string) (*Vote, error) {
+	id, err := strconv.ParseUint(proposalID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proposal ID %s: %v", proposalID, err)
+	}
+
+	var result struct {
+		Vote *struct {
+			Voter string          `json:"voter"`
+			Vote  json.RawMessage `json:"vote"`
+		} `json:"vote"`
+	}
+	query := map[string]interface{}{"get_vote": map[string]interface{}{"proposal_id": id, "voter": voterAddress}}
+	err = querySmart(ctx, client, chain, dao.ContractAddress, query, &result)
+	var statusErr *chainclient.StatusError
+	if errors.As(err, &statusErr) {
+		return nil, voteStatusError(proposalID, statusErr.StatusCode, "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: error fetching DAO vote status for proposal %s: %v", ErrVoteStatusTransient, proposalID, err)
+	}
+	if result.Vote == nil || result.Vote.Voter != voterAddress {
+		return nil, nil
+	}
+	return newVote(voterAddress, nil, daoVoteOption(result.Vote.Vote), ""), nil
+}
+
+// daoVoteOption reads the vote of dao-proposal-single, "yes", "no" or "abstain", or the chosen option
+// of dao-proposal-multiple, {"option_id": 1}
+func daoVoteOption(raw json.RawMessage) string {
+	var option string
+	if json.Unmarshal(raw, &option) == nil {
+		return option
+	}
+	var multiple struct {
+		OptionID *int `json:"option_id"`
+	}
+	if json.Unmarshal(raw, &multiple) == nil && multiple.OptionID != nil {
+		return fmt.Sprintf("option %d", *multiple.OptionID)
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package proposals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// SourceGroup marks proposals of the x/group module
+const SourceGroup = "group"
+
+// GroupProposal represents the structure of x/group proposals
+type GroupProposal struct {
+	ID                 string `json:"id"`
+	GroupPolicyAddress string `json:"group_policy_address"`
+	Metadata           string `json:"metadata"`
+	Status             string `json:"status"`
+	SubmitTime         string `json:"submit_time"`
+	VotingPeriodEnd    string `json:"voting_period_end"`
+	// Title and Summary are only set by SDK 0.47 and later
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+}
+
+type GroupVoteResponse struct {
+	Vote struct {
+		ProposalID string `json:"proposal_id"`
+		Voter      string `json:"voter"`
+		Option     string `json:"option"`
+		Metadata   string `json:"metadata"`
+	} `json:"vote"`
+}
+
+// groupStatuses maps x/group proposal statuses onto the gov statuses used by the monitor. Group
+// proposals can be voted on as soon as they are submitted.
+var groupStatuses = map[string]string{
+	"PROPOSAL_STATUS_SUBMITTED": ProposalStatusVotingPeriod,
+	"PROPOSAL_STATUS_ACCEPTED":  ProposalStatusPassed,
+	"PROPOSAL_STATUS_REJECTED":  ProposalStatusRejected,
+	"PROPOSAL_STATUS_ABORTED":   ProposalStatusFailed,
+	"PROPOSAL_STATUS_WITHDRAWN": ProposalStatusFailed,
+}
+
+// FetchGroupProposals returns the proposals of the configured group policy, or of every policy of the
+// configured group
+func FetchGroupProposals(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, group config.GroupConfig) ([]Proposal, error) {
+	policies := []string{group.PolicyAddress}
+	if group.PolicyAddress == "" {
+		var err error
+		policies, err = fetchGroupPolicies(ctx, client, chain, group.GroupID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mapped []Proposal
+	for _, policy := range policies {
+		var result struct {
+			Proposals []GroupProposal `json:"proposals"`
+		}
+		err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/group/v1/proposals_by_group_policy/%s?pagination.reverse=true", chain.APIEndpoint, policy), &result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch proposals of group policy %s: %v", policy, err)
+		}
+		mapped = append(mapped, mapGroupProposals(result.Proposals)...)
+	}
+	return mapped, nil
+}
+
+func fetchGroupPolicies(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, groupID string) ([]string, error) {
+	var result struct {
+		GroupPolicies []struct {
+			Address string `json:"address"`
+		} `json:"group_policies"`
+	}
+	err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/group/v1/group_policies_by_group/%s", chain.APIEndpoint, groupID), &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch policies of group %s: %v", groupID, err)
+	}
+
+	var policies []string
+	for _, policy := range result.GroupPolicies {
+		policies = append(policies, policy.Address)
+	}
+	return policies, nil
+}
+
+func mapGroupProposals(proposals []GroupProposal) []Proposal {
+	var mapped []Proposal
+	for _, p := range proposals {
+		title := p.Title
+		if title == "" {
+			title = groupMetadataTitle(p.Metadata)
+		}
+		if title == "" {
+			title = "No Title"
+		}
+		description := p.Summary
+		if description == "" {
+			description = fmt.Sprintf("Group policy %s", p.GroupPolicyAddress)
+		}
+
+		status, ok := groupStatuses[p.Status]
+		if !ok {
+			status = ProposalStatusUnspecified
+		}
+
+		mapped = append(mapped, Proposal{
+			ProposalID:      p.ID,
+			Status:          status,
+			Title:           title,
+			Description:     description,
+			VotingStartTime: p.SubmitTime,
+			VotingEndTime:   p.VotingPeriodEnd,
+			Source:          SourceGroup,
+			DetailURL:       "-",
+		})
+	}
+	return mapped
+}
+
+// groupMetadataTitle reads the title from proposal metadata following the x/group JSON convention
+func groupMetadataTitle(metadata string) string {
+	var parsed struct {
+		Title string `json:"title"`
+	}
+	if json.Unmarshal([]byte(metadata), &parsed) != nil {
+		return ""
+	}
+	return parsed.Title
+}
+
+// FetchGroupMemberVote returns the vote of the member on the proposal, or nil if it hasn't voted
+func FetchGroupMemberVote(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, proposalID string, memberAddress string) (*Vote, error) {
+	voteCheckURL := fmt.Sprintf("%s/cosmos/group/v1/vote_by_proposal_voter/%s/%s", chain.APIEndpoint, proposalID, memberAddress)
+	body, statusCode, err := client.Get(ctx, voteCheckURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error fetching group vote status for proposal %s: %v", ErrVoteStatusTransient, proposalID, err)
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, classifyVoteResponse(proposalID, statusCode, body)
+	}
+
+	var voteResponse GroupVoteResponse
+	err = json.Unmarshal(body, &voteResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding group vote response for proposal %s: %w", proposalID, err)
+	}
+	if voteResponse.Vote.Voter != memberAddress {
+		return nil, nil
+	}
+	return newVote(memberAddress, nil, voteResponse.Vote.Option, voteResponse.Vote.Metadata), nil
+}
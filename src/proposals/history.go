@@ -0,0 +1,94 @@
+package proposals
+
+import (
+	"context"
+	"time"
+)
+
+// VoteRecord is the participation of one of our voters in a proposal: how and when it voted, or
+// that it missed the vote
+type VoteRecord struct {
+	StateKey        string `firestore:"state_key" json:"state_key"`
+	ChainName       string `firestore:"chain_name" json:"chain_name"`
+	ProposalID      string `firestore:"proposal_id" json:"proposal_id"`
+	Title           string `firestore:"title" json:"title"`
+	Status          string `firestore:"status" json:"status"`
+	VotingStartTime string `firestore:"voting_start_time" json:"voting_start_time"`
+	VotingEndTime   string `firestore:"voting_end_time" json:"voting_end_time"`
+	Voter           string `firestore:"voter" json:"voter"`
+	VoterLabel      string `firestore:"voter_label" json:"voter_label"`
+	Vote            *Vote  `firestore:"vote" json:"vote,omitempty"`
+	// VotedAt is the time of the vote transaction, or the time the vote was first seen when the
+	// transaction can't be found, in which case VotedAtEstimated is set. It is nil for votes found
+	// only after the proposal closed, whose transaction can't be found.
+	VotedAt          *time.Time `firestore:"voted_at" json:"voted_at,omitempty"`
+	VotedAtEstimated bool       `firestore:"voted_at_estimated" json:"voted_at_estimated,omitempty"`
+	TxHash           string     `firestore:"tx_hash" json:"tx_hash,omitempty"`
+	Missed           bool       `firestore:"missed" json:"missed"`
+	// VoteStatusUnknown is set when the vote couldn't be looked up once voting ended, so the record
+	// counts neither as voted nor as missed
+	VoteStatusUnknown bool `firestore:"vote_status_unknown" json:"vote_status_unknown,omitempty"`
+}
+
+// Key identifies the record of the voter for the proposal
+func (r VoteRecord) Key() string {
+	return r.StateKey + "/" + r.ProposalID + "/" + r.Voter
+}
+
+// Voted reports whether the voter is known to have voted
+func (r VoteRecord) Voted() bool {
+	return !r.Missed && !r.VoteStatusUnknown
+}
+
+// TimeToVote is how long after the start of the voting period the vote was cast
+func (r VoteRecord) TimeToVote() (time.Duration, bool) {
+	start, err := time.Parse(time.RFC3339, r.VotingStartTime)
+	if err != nil || r.VotedAt == nil {
+		return 0, false
+	}
+	return r.VotedAt.Sub(start), true
+}
+
+// DeadlineMargin is how long before the end of the voting period the vote was cast
+func (r VoteRecord) DeadlineMargin() (time.Duration, bool) {
+	end, err := time.Parse(time.RFC3339, r.VotingEndTime)
+	if err != nil || r.VotedAt == nil {
+		return 0, false
+	}
+	return end.Sub(*r.VotedAt), true
+}
+
+func (c *FirestoreHandler) GetVoteHistory(ctx context.Context) ([]VoteRecord, error) {
+	docs, err := c.recordsCollection(CollectionNameVoteHistory).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []VoteRecord
+	for _, dsnap := range docs {
+		var record VoteRecord
+		err = dsnap.DataTo(&record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// SaveVoteRecord stores the record in its own document, replacing the earlier record of the voter
+// for the proposal
+func (c *FirestoreHandler) SaveVoteRecord(ctx context.Context, record VoteRecord) error {
+	_, err := c.recordsCollection(CollectionNameVoteHistory).Doc(recordDocID(record.Key())).Set(ctx, record)
+	return err
+}
+
+func withVoteRecord(records []VoteRecord, record VoteRecord) []VoteRecord {
+	for i := range records {
+		if records[i].Key() == record.Key() {
+			records[i] = record
+			return records
+		}
+	}
+	return append(records, record)
+}
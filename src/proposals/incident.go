@@ -0,0 +1,71 @@
+package proposals
+
+import (
+	"context"
+	"time"
+)
+
+// Reminder is an alert sent about a proposal while our voters could still vote on it
+type Reminder struct {
+	Alert  string    `firestore:"alert" json:"alert"`
+	SentAt time.Time `firestore:"sent_at" json:"sent_at"`
+}
+
+// Incident records a proposal whose voting period ended without a vote from one of our voters,
+// along with the reminders that were sent about it
+type Incident struct {
+	StateKey      string     `firestore:"state_key" json:"state_key"`
+	ChainName     string     `firestore:"chain_name" json:"chain_name"`
+	ProposalID    string     `firestore:"proposal_id" json:"proposal_id"`
+	Title         string     `firestore:"title" json:"title"`
+	Status        string     `firestore:"status" json:"status"`
+	VotingEndTime string     `firestore:"voting_end_time" json:"voting_end_time"`
+	Voter         string     `firestore:"voter" json:"voter"`
+	VoterLabel    string     `firestore:"voter_label" json:"voter_label"`
+	Reminders     []Reminder `firestore:"reminders" json:"reminders"`
+	// VoteStatusUnknown is set when the vote couldn't be looked up once voting ended, or wasn't found
+	// on a node that no longer indexes the proposal's transactions, so the last known status, not
+	// voted, was used
+	VoteStatusUnknown bool      `firestore:"vote_status_unknown" json:"vote_status_unknown,omitempty"`
+	RecordedAt        time.Time `firestore:"recorded_at" json:"recorded_at"`
+}
+
+// Key identifies the incident, so it is only recorded once
+func (i Incident) Key() string {
+	return i.StateKey + "/" + i.ProposalID + "/" + i.Voter
+}
+
+func (c *FirestoreHandler) GetIncidents(ctx context.Context) ([]Incident, error) {
+	docs, err := c.recordsCollection(CollectionNameIncidents).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var incidents []Incident
+	for _, dsnap := range docs {
+		var incident Incident
+		err = dsnap.DataTo(&incident)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, nil
+}
+
+// SaveIncident stores the incident in its own document, replacing an earlier record of the same
+// incident
+func (c *FirestoreHandler) SaveIncident(ctx context.Context, incident Incident) error {
+	_, err := c.recordsCollection(CollectionNameIncidents).Doc(recordDocID(incident.Key())).Set(ctx, incident)
+	return err
+}
+
+func withIncident(incidents []Incident, incident Incident) []Incident {
+	for i := range incidents {
+		if incidents[i].Key() == incident.Key() {
+			incidents[i] = incident
+			return incidents
+		}
+	}
+	return append(incidents, incident)
+}
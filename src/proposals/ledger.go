@@ -0,0 +1,51 @@
+package proposals
+
+import (
+	"context"
+	"time"
+)
+
+// EmittedEvent is an entry of the event ledger: an event published for a proposal stream, or another
+// alert keyed in it, which is never sent again once recorded
+type EmittedEvent struct {
+	StateKey  string    `firestore:"state_key" json:"state_key"`
+	Key       string    `firestore:"key" json:"key"`
+	EmittedAt time.Time `firestore:"emitted_at" json:"emitted_at"`
+}
+
+// GetEmittedEvents returns the keys of the event ledger by state key. Ledgers saved as a single
+// document, before every entry had its own, are read from that document as well.
+func (c *FirestoreHandler) GetEmittedEvents(ctx context.Context) (map[string]map[string]bool, error) {
+	emitted, err := c.GetAlertedProposals(ctx, CollectionNameEmittedEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := c.recordsCollection(CollectionNameEmittedEvents).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, dsnap := range docs {
+		var event EmittedEvent
+		err = dsnap.DataTo(&event)
+		if err != nil {
+			return nil, err
+		}
+		withEmittedEvent(emitted, event)
+	}
+	return emitted, nil
+}
+
+// SaveEmittedEvent stores the entry in its own document, so recording an event doesn't rewrite the
+// whole ledger
+func (c *FirestoreHandler) SaveEmittedEvent(ctx context.Context, event EmittedEvent) error {
+	_, err := c.recordsCollection(CollectionNameEmittedEvents).Doc(recordDocID(event.StateKey+"/"+event.Key)).Set(ctx, event)
+	return err
+}
+
+func withEmittedEvent(emitted map[string]map[string]bool, event EmittedEvent) {
+	if emitted[event.StateKey] == nil {
+		emitted[event.StateKey] = make(map[string]bool)
+	}
+	emitted[event.StateKey][event.Key] = true
+}
@@ -0,0 +1,188 @@
+package proposals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// NamadaProposalLimit is the number of most recent Namada proposals processed on every run
+const NamadaProposalLimit = 100
+
+// NamadaProposal represents a proposal returned by the Namada indexer
+type NamadaProposal struct {
+	ID           string `json:"id"`
+	Content      string `json:"content"`
+	Type         string `json:"type"`
+	Status       string `json:"status"`
+	StartTime    string `json:"startTime"`
+	EndTime      string `json:"endTime"`
+	YayVotes     string `json:"yayVotes"`
+	NayVotes     string `json:"nayVotes"`
+	AbstainVotes string `json:"abstainVotes"`
+}
+
+type namadaVote struct {
+	ProposalID   uint64 `json:"proposalId"`
+	Vote         string `json:"vote"`
+	VoterAddress string `json:"voterAddress"`
+}
+
+// namadaStatuses maps Namada proposal statuses onto the gov statuses used by the monitor. Namada has
+// no deposit period, so pending proposals, whose voting hasn't started yet, are treated as such.
+var namadaStatuses = map[string]string{
+	"pending":  ProposalStatusDepositPeriod,
+	"voting":   ProposalStatusVotingPeriod,
+	"passed":   ProposalStatusPassed,
+	"rejected": ProposalStatusRejected,
+}
+
+// NamadaSource reads Namada governance through the REST API of the Namada indexer, given as the
+// chain's api_endpoint, since Namada nodes don't serve one
+type NamadaSource struct {
+	Client *chainclient.Client
+	Chain  config.ChainConfig
+}
+
+func (s *NamadaSource) ListProposals(ctx context.Context) ([]Proposal, error) {
+	var result []NamadaProposal
+	err := s.Client.GetJSON(ctx, fmt.Sprintf("%s/api/v1/gov/proposal/all", s.Chain.APIEndpoint), &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch proposals: %v", err)
+	}
+
+	// Newest first and limited, like the x/gov proposals are
+	sort.Slice(result, func(i, j int) bool {
+		return namadaProposalID(result[i]) > namadaProposalID(result[j])
+	})
+	if len(result) > NamadaProposalLimit {
+		result = result[:NamadaProposalLimit]
+	}
+
+	var mapped []Proposal
+	for _, p := range result {
+		proposal, err := mapNamadaProposal(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proposal %s: %v", p.ID, err)
+		}
+		mapped = append(mapped, proposal)
+	}
+	return mapped, nil
+}
+
+func (s *NamadaSource) GetVote(ctx context.Context, proposalID string, voter string) (*Vote, error) {
+	voteCheckURL := fmt.Sprintf("%s/api/v1/gov/proposal/%s/votes/%s", s.Chain.APIEndpoint, proposalID, voter)
+	body, statusCode, err := s.Client.Get(ctx, voteCheckURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error fetching vote status for proposal %s: %v", ErrVoteStatusTransient, proposalID, err)
+	}
+
+	// The indexer answers with an empty list when there is no vote, so any other status is an error
+	if statusCode != http.StatusOK {
+		return nil, voteStatusError(proposalID, statusCode, "")
+	}
+
+	var votes []namadaVote
+	err = json.Unmarshal(body, &votes)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding vote response for proposal %s: %w", proposalID, err)
+	}
+	for _, vote := range votes {
+		if vote.VoterAddress == voter {
+			return newVote(voter, nil, vote.Vote, ""), nil
+		}
+	}
+	return nil, nil
+}
+
+// Tally returns the voting power behind each option. Namada has no veto option.
+func (s *NamadaSource) Tally(ctx context.Context, proposalID string) (*Tally, error) {
+	var p NamadaProposal
+	err := s.Client.GetJSON(ctx, fmt.Sprintf("%s/api/v1/gov/proposal/%s", s.Chain.APIEndpoint, proposalID), &p)
+	if err != nil {
+		return nil, err
+	}
+	return &Tally{
+		Yes:        namadaAmount(p.YayVotes),
+		Abstain:    namadaAmount(p.AbstainVotes),
+		No:         namadaAmount(p.NayVotes),
+		NoWithVeto: new(big.Int),
+	}, nil
+}
+
+func mapNamadaProposal(p NamadaProposal) (Proposal, error) {
+	votingStartTime, err := namadaTime(p.StartTime)
+	if err != nil {
+		return Proposal{}, fmt.Errorf("invalid start time: %v", err)
+	}
+	votingEndTime, err := namadaTime(p.EndTime)
+	if err != nil {
+		return Proposal{}, fmt.Errorf("invalid end time: %v", err)
+	}
+
+	// The content is the JSON proposal document submitted on chain
+	var content struct {
+		Title    string `json:"title"`
+		Abstract string `json:"abstract"`
+		Details  string `json:"details"`
+	}
+	_ = json.Unmarshal([]byte(p.Content), &content)
+
+	title := content.Title
+	if title == "" {
+		title = "No Title"
+	}
+	description := content.Abstract
+	if description == "" {
+		description = content.Details
+	}
+	if description == "" {
+		description = "No Description"
+	}
+
+	status, ok := namadaStatuses[p.Status]
+	if !ok {
+		status = ProposalStatusUnspecified
+	}
+
+	return Proposal{
+		ProposalID:      p.ID,
+		Status:          status,
+		Title:           title,
+		Description:     description,
+		VotingStartTime: votingStartTime.UTC().Format(time.RFC3339),
+		VotingEndTime:   votingEndTime.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// namadaTime parses an indexer timestamp, given in unix seconds
+func namadaTime(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+func namadaProposalID(p NamadaProposal) uint64 {
+	id, _ := strconv.ParseUint(p.ID, 10, 64)
+	return id
+}
+
+// namadaAmount parses a voting power, which the indexer may give with decimals. The fraction is
+// dropped, as only the ratios between amounts matter.
+func namadaAmount(value string) *big.Int {
+	amount, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return new(big.Int)
+	}
+	return new(big.Int).Quo(amount.Num(), amount.Denom())
+}
@@ -0,0 +1,338 @@
+package proposals
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// Message and legacy content types that change governance parameters
+const (
+	TypeMsgUpdateGovParams      = "/cosmos.gov.v1.MsgUpdateParams"
+	TypeParameterChangeProposal = "/cosmos.params.v1beta1.ParameterChangeProposal"
+)
+
+// GovParamsTTL is how long fetched gov params are reused before being fetched again
+const GovParamsTTL = time.Hour
+
+type Coin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+// GovParams are the governance parameters of a chain that alerts and timing decisions depend on
+type GovParams struct {
+	VotingPeriod          time.Duration `json:"voting_period"`
+	ExpeditedVotingPeriod time.Duration `json:"expedited_voting_period,omitempty"`
+	MinDeposit            []Coin        `json:"min_deposit"`
+	Quorum                float64       `json:"quorum"`
+	Threshold             float64       `json:"threshold"`
+	VetoThreshold         float64       `json:"veto_threshold"`
+	ExpeditedThreshold    float64       `json:"expedited_threshold,omitempty"`
+}
+
+// govParamsResponse covers the params endpoints of every SDK version: v1 from SDK 0.47 returns
+// everything in params, while older versions only fill the section of the requested params type
+type govParamsResponse struct {
+	Params *struct {
+		MinDeposit            []Coin `json:"min_deposit"`
+		VotingPeriod          string `json:"voting_period"`
+		ExpeditedVotingPeriod string `json:"expedited_voting_period"`
+		Quorum                string `json:"quorum"`
+		Threshold             string `json:"threshold"`
+		VetoThreshold         string `json:"veto_threshold"`
+		ExpeditedThreshold    string `json:"expedited_threshold"`
+	} `json:"params"`
+	VotingParams struct {
+		VotingPeriod string `json:"voting_period"`
+	} `json:"voting_params"`
+	DepositParams struct {
+		MinDeposit []Coin `json:"min_deposit"`
+	} `json:"deposit_params"`
+	TallyParams struct {
+		Quorum        string `json:"quorum"`
+		Threshold     string `json:"threshold"`
+		VetoThreshold string `json:"veto_threshold"`
+	} `json:"tally_params"`
+}
+
+// FetchGovParams fetches the governance parameters of the chain. Decimals are given as decimal
+// strings, except for the tally params of the v1beta1 endpoint before SDK 0.46, which are base64
+// encoded.
+func FetchGovParams(ctx context.Context, client *chainclient.Client, chain config.ChainConfig) (*GovParams, error) {
+	var params GovParams
+	for _, paramsType := range []string{"voting", "deposit", "tallying"} {
+		var resp govParamsResponse
+		err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/gov/%s/params/%s", chain.APIEndpoint, chain.APIVersion, paramsType), &resp)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %s params: %v", paramsType, err)
+		}
+
+		if resp.Params != nil && resp.Params.VotingPeriod != "" {
+			return govParamsFromV1(resp)
+		}
+
+		switch paramsType {
+		case "voting":
+			params.VotingPeriod, err = parseProtoDuration(resp.VotingParams.VotingPeriod)
+		case "deposit":
+			params.MinDeposit = resp.DepositParams.MinDeposit
+		case "tallying":
+			params.Quorum, err = parseLegacyTallyDec(resp.TallyParams.Quorum)
+			if err == nil {
+				params.Threshold, err = parseLegacyTallyDec(resp.TallyParams.Threshold)
+			}
+			if err == nil {
+				params.VetoThreshold, err = parseLegacyTallyDec(resp.TallyParams.VetoThreshold)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s params: %v", paramsType, err)
+		}
+	}
+	return &params, nil
+}
+
+func govParamsFromV1(resp govParamsResponse) (*GovParams, error) {
+	p := resp.Params
+	params := &GovParams{MinDeposit: p.MinDeposit}
+
+	var err error
+	params.VotingPeriod, err = parseProtoDuration(p.VotingPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing voting period: %v", err)
+	}
+	if p.ExpeditedVotingPeriod != "" {
+		params.ExpeditedVotingPeriod, err = parseProtoDuration(p.ExpeditedVotingPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing expedited voting period: %v", err)
+		}
+	}
+	for _, field := range []struct {
+		value string
+		out   *float64
+	}{
+		{p.Quorum, &params.Quorum},
+		{p.Threshold, &params.Threshold},
+		{p.VetoThreshold, &params.VetoThreshold},
+		{p.ExpeditedThreshold, &params.ExpeditedThreshold},
+	} {
+		if field.value == "" {
+			continue
+		}
+		*field.out, err = parseDec(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing tally params: %v", err)
+		}
+	}
+	return params, nil
+}
+
+// parseProtoDuration parses a protobuf JSON duration such as "1209600s"
+func parseProtoDuration(value string) (time.Duration, error) {
+	return time.ParseDuration(value)
+}
+
+// parseDec parses an SDK decimal given as a decimal string, such as "0.334000000000000000"
+func parseDec(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseLegacyTallyDec parses a tally param of the v1beta1 params endpoint, a decimal string from SDK
+// 0.46 and, before, the base64 encoded bytes of the decimal's integer representation, with 18
+// decimal places
+func parseLegacyTallyDec(value string) (float64, error) {
+	if strings.Contains(value, ".") {
+		return parseDec(value)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid decimal %q", value)
+	}
+	integer, ok := new(big.Int).SetString(string(decoded), 10)
+	if !ok {
+		return 0, fmt.Errorf("invalid decimal %q", value)
+	}
+	dec, _ := new(big.Rat).SetFrac(integer, big.NewInt(1e18)).Float64()
+	return dec, nil
+}
+
+type cachedGovParams struct {
+	params    *GovParams
+	fetchedAt time.Time
+}
+
+// GovParamsCache keeps the gov params of every chain for GovParamsTTL, so they aren't fetched on every run
+type GovParamsCache struct {
+	mu     sync.Mutex
+	client *chainclient.Client
+	params map[string]cachedGovParams
+}
+
+func NewGovParamsCache(client *chainclient.Client) *GovParamsCache {
+	return &GovParamsCache{client: client, params: make(map[string]cachedGovParams)}
+}
+
+func (c *GovParamsCache) Get(ctx context.Context, chainName string, chain config.ChainConfig) (*GovParams, error) {
+	c.mu.Lock()
+	cached, ok := c.params[chainName]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < GovParamsTTL {
+		return cached.params, nil
+	}
+
+	params, err := FetchGovParams(ctx, c.client, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.params[chainName] = cachedGovParams{params: params, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return params, nil
+}
+
+// Invalidate drops the cached params of the chain, after a proposal changing them has passed
+func (c *GovParamsCache) Invalidate(chainName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.params, chainName)
+}
+
+// changesGovParams reports whether a message or legacy content changes the gov module parameters
+func changesGovParams(messageType string, changes []paramChange) bool {
+	if messageType == TypeMsgUpdateGovParams {
+		return true
+	}
+	if messageType == TypeParameterChangeProposal {
+		for _, change := range changes {
+			if change.Subspace == "gov" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// changesGovParamsV1 looks for a gov params change among the messages of a v1 proposal, either as a
+// MsgUpdateParams or as legacy content
+func changesGovParamsV1(messages []proposalMessage) bool {
+	for _, message := range messages {
+		if changesGovParams(message.Type, nil) || changesGovParams(message.Content.Type, message.Content.Changes) {
+			return true
+		}
+	}
+	return false
+}
+
+type paramChange struct {
+	Subspace string `json:"subspace"`
+	Key      string `json:"key"`
+}
+
+// Tally is the current tally of a proposal
+type Tally struct {
+	Yes        *big.Int
+	Abstain    *big.Int
+	No         *big.Int
+	NoWithVeto *big.Int
+}
+
+// FetchTally returns the current tally of a proposal in its voting period
+func FetchTally(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, proposalID string) (*Tally, error) {
+	var resp struct {
+		Tally map[string]string `json:"tally"`
+	}
+	err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/gov/%s/proposals/%s/tally", chain.APIEndpoint, chain.APIVersion, proposalID), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// v1 suffixes the fields with _count
+	field := func(name string) *big.Int {
+		value, ok := resp.Tally[name]
+		if !ok {
+			value = resp.Tally[name+"_count"]
+		}
+		amount, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return new(big.Int)
+		}
+		return amount
+	}
+	return &Tally{
+		Yes:        field("yes"),
+		Abstain:    field("abstain"),
+		No:         field("no"),
+		NoWithVeto: field("no_with_veto"),
+	}, nil
+}
+
+// FetchBondedTokens returns the total bonded tokens, against which the quorum is measured
+func FetchBondedTokens(ctx context.Context, client *chainclient.Client, chain config.ChainConfig) (*big.Int, error) {
+	var resp struct {
+		Pool struct {
+			BondedTokens string `json:"bonded_tokens"`
+		} `json:"pool"`
+	}
+	err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/staking/v1beta1/pool", chain.APIEndpoint), &resp)
+	if err != nil {
+		return nil, err
+	}
+	bonded, ok := new(big.Int).SetString(resp.Pool.BondedTokens, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid bonded tokens %q", resp.Pool.BondedTokens)
+	}
+	return bonded, nil
+}
+
+// TallyOutcome is the tally of a proposal measured against the gov params of its chain
+type TallyOutcome struct {
+	Turnout   float64
+	YesShare  float64
+	VetoShare float64
+	QuorumMet bool
+	Vetoed    bool
+	WouldPass bool
+	Threshold float64
+}
+
+// EvaluateTally applies the gov tally rules: the quorum is measured over all votes against bonded
+// tokens, the veto share over all votes, and the threshold over the non-abstaining votes
+func EvaluateTally(tally *Tally, bonded *big.Int, params *GovParams, expedited bool) TallyOutcome {
+	threshold := params.Threshold
+	if expedited && params.ExpeditedThreshold > 0 {
+		threshold = params.ExpeditedThreshold
+	}
+	outcome := TallyOutcome{Threshold: threshold}
+
+	total := new(big.Int).Add(tally.Yes, tally.Abstain)
+	total.Add(total, tally.No)
+	total.Add(total, tally.NoWithVeto)
+	nonAbstain := new(big.Int).Sub(total, tally.Abstain)
+
+	outcome.Turnout = ratio(total, bonded)
+	outcome.VetoShare = ratio(tally.NoWithVeto, total)
+	outcome.YesShare = ratio(tally.Yes, nonAbstain)
+	outcome.QuorumMet = bonded.Sign() > 0 && outcome.Turnout >= params.Quorum
+	outcome.Vetoed = total.Sign() > 0 && outcome.VetoShare > params.VetoThreshold
+	outcome.WouldPass = outcome.QuorumMet && !outcome.Vetoed && nonAbstain.Sign() > 0 && outcome.YesShare > threshold
+	return outcome
+}
+
+func ratio(numerator, denominator *big.Int) float64 {
+	if denominator.Sign() == 0 {
+		return 0
+	}
+	r, _ := new(big.Rat).SetFrac(numerator, denominator).Float64()
+	return r
+}
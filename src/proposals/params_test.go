@@ -0,0 +1,208 @@
+package proposals
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// newTestChain serves the LCD of a chain from the handler, with a client that doesn't retry
+func newTestChain(t *testing.T, handler http.HandlerFunc) (*chainclient.Client, config.ChainConfig) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	maxRetries := 0
+	client := chainclient.New(config.HTTPConfig{MaxRetries: &maxRetries})
+	return client, config.ChainConfig{APIEndpoint: server.URL, APIVersion: "v1beta1"}
+}
+
+// serveResponses answers every path with its response, and any other path with a 501 as the gateway does
+func serveResponses(responses map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotImplemented)
+			w.Write([]byte(`{"code":12,"message":"Not Implemented"}`))
+			return
+		}
+		w.Write([]byte(response))
+	}
+}
+
+func TestFetchGovParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		responses map[string]string
+		want      GovParams
+	}{
+		{
+			// SDK 0.45 gives the tally params as the base64 encoded integers of their 18 decimal places
+			name:    "v1beta1",
+			version: "v1beta1",
+			responses: map[string]string{
+				"/cosmos/gov/v1beta1/params/voting":   `{"voting_params":{"voting_period":"1209600s"},"deposit_params":{"min_deposit":[],"max_deposit_period":"0s"},"tally_params":{"quorum":"","threshold":"","veto_threshold":""}}`,
+				"/cosmos/gov/v1beta1/params/deposit":  `{"voting_params":{"voting_period":"0s"},"deposit_params":{"min_deposit":[{"denom":"uatom","amount":"250000000"}],"max_deposit_period":"1209600s"},"tally_params":{"quorum":"","threshold":"","veto_threshold":""}}`,
+				"/cosmos/gov/v1beta1/params/tallying": `{"voting_params":{"voting_period":"0s"},"deposit_params":{"min_deposit":[],"max_deposit_period":"0s"},"tally_params":{"quorum":"NDAwMDAwMDAwMDAwMDAwMDAw","threshold":"NTAwMDAwMDAwMDAwMDAwMDAw","veto_threshold":"MzM0MDAwMDAwMDAwMDAwMDAw"}}`,
+			},
+			want: GovParams{
+				VotingPeriod:  14 * 24 * time.Hour,
+				MinDeposit:    []Coin{{Denom: "uatom", Amount: "250000000"}},
+				Quorum:        0.4,
+				Threshold:     0.5,
+				VetoThreshold: 0.334,
+			},
+		},
+		{
+			name:    "v1",
+			version: "v1",
+			responses: map[string]string{
+				"/cosmos/gov/v1/params/voting": `{"voting_params":{"voting_period":"1209600s"},"deposit_params":null,"tally_params":null,"params":{"min_deposit":[{"denom":"uatom","amount":"250000000"}],"max_deposit_period":"1209600s","voting_period":"1209600s","quorum":"0.200000000000000000","threshold":"0.500000000000000000","veto_threshold":"0.334000000000000000","min_initial_deposit_ratio":"0.250000000000000000","proposal_cancel_ratio":"0.500000000000000000","proposal_cancel_dest":"","expedited_voting_period":"86400s","expedited_threshold":"0.667000000000000000","expedited_min_deposit":[{"denom":"uatom","amount":"500000000"}],"burn_vote_quorum":false,"burn_proposal_deposit_prevote":false,"burn_vote_veto":true,"min_deposit_ratio":"0.010000000000000000"}}`,
+			},
+			want: GovParams{
+				VotingPeriod:          14 * 24 * time.Hour,
+				ExpeditedVotingPeriod: 24 * time.Hour,
+				MinDeposit:            []Coin{{Denom: "uatom", Amount: "250000000"}},
+				Quorum:                0.2,
+				Threshold:             0.5,
+				VetoThreshold:         0.334,
+				ExpeditedThreshold:    0.667,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, chain := newTestChain(t, serveResponses(test.responses))
+			chain.APIVersion = test.version
+
+			params, err := FetchGovParams(context.Background(), client, chain)
+			if err != nil {
+				t.Fatalf("FetchGovParams failed: %v", err)
+			}
+			if params.VotingPeriod != test.want.VotingPeriod || params.ExpeditedVotingPeriod != test.want.ExpeditedVotingPeriod {
+				t.Errorf("voting periods = %v, %v, want %v, %v", params.VotingPeriod, params.ExpeditedVotingPeriod, test.want.VotingPeriod, test.want.ExpeditedVotingPeriod)
+			}
+			if len(params.MinDeposit) != len(test.want.MinDeposit) || (len(params.MinDeposit) > 0 && params.MinDeposit[0] != test.want.MinDeposit[0]) {
+				t.Errorf("min deposit = %v, want %v", params.MinDeposit, test.want.MinDeposit)
+			}
+			for _, field := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"quorum", params.Quorum, test.want.Quorum},
+				{"threshold", params.Threshold, test.want.Threshold},
+				{"veto threshold", params.VetoThreshold, test.want.VetoThreshold},
+				{"expedited threshold", params.ExpeditedThreshold, test.want.ExpeditedThreshold},
+			} {
+				if math.Abs(field.got-field.want) > 1e-12 {
+					t.Errorf("%s = %v, want %v", field.name, field.got, field.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDec(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		valid bool
+	}{
+		{"0.334000000000000000", 0.334, true},
+		{"1.000000000000000000", 1, true},
+		{"1", 1, true},
+		{"1000", 1000, true},
+		{"MzM0MDAwMDAwMDAwMDAwMDAw", 0, false},
+		{"not a decimal", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			testDecParser(t, parseDec, test.value, test.want, test.valid)
+		})
+	}
+}
+
+func TestParseLegacyTallyDec(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		valid bool
+	}{
+		{"0.334000000000000000", 0.334, true},
+		{"MzM0MDAwMDAwMDAwMDAwMDAw", 0.334, true},
+		{"MTAwMDAwMDAwMDAwMDAwMDAwMA==", 1, true},
+		// Valid base64, but not of an integer
+		{"1000", 0, false},
+		{"not a decimal", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			testDecParser(t, parseLegacyTallyDec, test.value, test.want, test.valid)
+		})
+	}
+}
+
+func testDecParser(t *testing.T, parse func(string) (float64, error), value string, want float64, valid bool) {
+	t.Helper()
+	got, err := parse(value)
+	if !valid {
+		if err == nil {
+			t.Fatalf("parsing %q = %v, want an error", value, got)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("parsing %q failed: %v", value, err)
+	}
+	if math.Abs(got-want) > 1e-12 {
+		t.Errorf("parsing %q = %v, want %v", value, got, want)
+	}
+}
+
+func TestEvaluateTally(t *testing.T) {
+	params := &GovParams{Quorum: 0.4, Threshold: 0.5, VetoThreshold: 0.334, ExpeditedThreshold: 0.667}
+	tally := func(yes, abstain, no, veto int64) *Tally {
+		return &Tally{Yes: big.NewInt(yes), Abstain: big.NewInt(abstain), No: big.NewInt(no), NoWithVeto: big.NewInt(veto)}
+	}
+	tests := []struct {
+		name      string
+		tally     *Tally
+		bonded    int64
+		expedited bool
+		quorumMet bool
+		vetoed    bool
+		wouldPass bool
+	}{
+		{"passing", tally(30, 5, 10, 5), 100, false, true, false, true},
+		{"without quorum", tally(30, 0, 5, 0), 100, false, false, false, false},
+		// 40 of 70 non-abstaining votes are yes, although they are only 40% of all votes
+		{"threshold over non-abstaining votes", tally(40, 30, 30, 0), 100, false, true, false, true},
+		{"at the threshold", tally(25, 10, 25, 0), 100, false, true, false, false},
+		{"vetoed", tally(30, 0, 0, 20), 100, false, true, true, false},
+		{"only abstaining", tally(0, 50, 0, 0), 100, false, true, false, false},
+		{"below the expedited threshold", tally(30, 0, 20, 0), 100, true, true, false, false},
+		{"over the expedited threshold", tally(40, 10, 10, 0), 100, true, true, false, true},
+		{"no bonded tokens", tally(30, 0, 0, 0), 0, false, false, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outcome := EvaluateTally(test.tally, big.NewInt(test.bonded), params, test.expedited)
+			if outcome.QuorumMet != test.quorumMet || outcome.Vetoed != test.vetoed || outcome.WouldPass != test.wouldPass {
+				t.Errorf("EvaluateTally = quorum met %v, vetoed %v, would pass %v, want %v, %v, %v",
+					outcome.QuorumMet, outcome.Vetoed, outcome.WouldPass, test.quorumMet, test.vetoed, test.wouldPass)
+			}
+		})
+	}
+}
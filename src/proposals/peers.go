@@ -0,0 +1,149 @@
+package proposals
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// DefaultPeerValidators is the number of validators the peer vote snapshot covers when none is set
+const DefaultPeerValidators = 10
+
+// Options a peer vote snapshot reports for validators that haven't voted, or whose vote isn't known
+const (
+	PeerNotVoted    = "Not voted"
+	PeerVoteUnknown = "Unknown"
+)
+
+// maxBondedFetched bounds the bonded validators fetched in one page, which covers the active set of
+// every chain
+const maxBondedFetched = 500
+
+// PeerVote is how one of the validators with the most voting power voted on a proposal
+type PeerVote struct {
+	Moniker         string  `json:"moniker"`
+	OperatorAddress string  `json:"operator_address"`
+	Tokens          string  `json:"tokens"`
+	PowerShare      float64 `json:"power_share"`
+	Vote            *Vote   `json:"vote,omitempty"`
+	// Error is set when the vote couldn't be looked up
+	Error string `json:"error,omitempty"`
+}
+
+// PeerVoteSummary is the share of the voting power of the bonded set behind one option, among the
+// validators of a peer vote snapshot. Validators that split their vote count towards every option by
+// its weight, so the counts of all options add up to the number of validators.
+type PeerVoteSummary struct {
+	Option     string  `json:"option"`
+	Validators float64 `json:"validators"`
+	PowerShare float64 `json:"power_share"`
+}
+
+type bondedValidator struct {
+	OperatorAddress string `json:"operator_address"`
+	Tokens          string `json:"tokens"`
+	Description     struct {
+		Moniker string `json:"moniker"`
+	} `json:"description"`
+	tokens *big.Int
+}
+
+// FetchPeerVotes looks up how the count validators of the active set with the most voting power voted
+// on the proposal. The power share of each is measured against the whole bonded set.
+func FetchPeerVotes(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, proposalID string, count int) ([]PeerVote, error) {
+	var resp struct {
+		Validators []bondedValidator `json:"validators"`
+	}
+	err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/staking/v1beta1/validators?status=BOND_STATUS_BONDED&pagination.limit=%d", chain.APIEndpoint, maxBondedFetched), &resp)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching bonded validators: %v", err)
+	}
+
+	total := new(big.Int)
+	validators := resp.Validators[:0]
+	for _, validator := range resp.Validators {
+		tokens, ok := new(big.Int).SetString(validator.Tokens, 10)
+		if !ok {
+			continue
+		}
+		validator.tokens = tokens
+		total.Add(total, tokens)
+		validators = append(validators, validator)
+	}
+	sort.SliceStable(validators, func(i, j int) bool {
+		return validators[i].tokens.Cmp(validators[j].tokens) > 0
+	})
+	if count > 0 && len(validators) > count {
+		validators = validators[:count]
+	}
+
+	var peerVotes []PeerVote
+	for _, validator := range validators {
+		peerVote := PeerVote{
+			Moniker:         validator.Description.Moniker,
+			OperatorAddress: validator.OperatorAddress,
+			Tokens:          validator.Tokens,
+			PowerShare:      ratio(validator.tokens, total),
+		}
+		voter, err := AccountAddress(validator.OperatorAddress, chain.AccountPrefix)
+		if err == nil {
+			peerVote.Vote, err = FetchValidatorVote(ctx, client, chain, proposalID, voter, chain.APIVersion)
+		}
+		if err != nil {
+			peerVote.Error = err.Error()
+		}
+		peerVotes = append(peerVotes, peerVote)
+	}
+	return peerVotes, nil
+}
+
+// SummarizePeerVotes adds up the validators and voting power behind every option, splitting weighted
+// votes by their weights, ordered by power
+func SummarizePeerVotes(peerVotes []PeerVote) []PeerVoteSummary {
+	summaries := make(map[string]*PeerVoteSummary)
+	var order []string
+	add := func(option string, validators float64, share float64) {
+		summary, found := summaries[option]
+		if !found {
+			summary = &PeerVoteSummary{Option: option}
+			summaries[option] = summary
+			order = append(order, option)
+		}
+		summary.Validators += validators
+		summary.PowerShare += share
+	}
+
+	for _, peerVote := range peerVotes {
+		switch {
+		case peerVote.Error != "":
+			add(PeerVoteUnknown, 1, peerVote.PowerShare)
+		case peerVote.Vote == nil || len(peerVote.Vote.Options) == 0:
+			add(PeerNotVoted, 1, peerVote.PowerShare)
+		default:
+			for _, option := range peerVote.Vote.Options {
+				weight, err := parseDec(option.Weight)
+				if err != nil || len(peerVote.Vote.Options) == 1 {
+					weight = 1 / float64(len(peerVote.Vote.Options))
+				}
+				add(OptionName(option.Option), weight, peerVote.PowerShare*weight)
+			}
+		}
+	}
+
+	result := make([]PeerVoteSummary, 0, len(order))
+	for _, option := range order {
+		summary := *summaries[option]
+		// Weights are decimals, so sums are rounded to keep counts like 1.6 readable
+		summary.Validators = math.Round(summary.Validators*100) / 100
+		result = append(result, summary)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].PowerShare > result[j].PowerShare
+	})
+	return result
+}
@@ -0,0 +1,72 @@
+package proposals
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSummarizePeerVotes(t *testing.T) {
+	split := PeerVote{Moniker: "split", PowerShare: 0.4, Vote: &Vote{Options: []WeightedOption{
+		{Option: "VOTE_OPTION_YES", Weight: "0.700000000000000000"},
+		{Option: "VOTE_OPTION_ABSTAIN", Weight: "0.300000000000000000"},
+	}}}
+	yes := PeerVote{Moniker: "yes", PowerShare: 0.3, Vote: &Vote{Options: []WeightedOption{
+		{Option: "VOTE_OPTION_YES", Weight: "1.000000000000000000"},
+	}}}
+	failed := PeerVote{Moniker: "failed", PowerShare: 0.2, Error: "error fetching vote: 500 Internal Server Error"}
+	notVoted := PeerVote{Moniker: "not voted", PowerShare: 0.1}
+
+	tests := []struct {
+		name      string
+		peerVotes []PeerVote
+		expected  []PeerVoteSummary
+	}{
+		{"70/30 split vote", []PeerVote{split}, []PeerVoteSummary{
+			{Option: "Yes", Validators: 0.7, PowerShare: 0.28},
+			{Option: "Abstain", Validators: 0.3, PowerShare: 0.12},
+		}},
+		{"lookup error", []PeerVote{failed}, []PeerVoteSummary{
+			{Option: PeerVoteUnknown, Validators: 1, PowerShare: 0.2},
+		}},
+		{"validator with no vote", []PeerVote{notVoted}, []PeerVoteSummary{
+			{Option: PeerNotVoted, Validators: 1, PowerShare: 0.1},
+		}},
+		{"vote with no options", []PeerVote{{PowerShare: 0.1, Vote: &Vote{}}}, []PeerVoteSummary{
+			{Option: PeerNotVoted, Validators: 1, PowerShare: 0.1},
+		}},
+		{"weight of a single option", []PeerVote{{PowerShare: 0.3, Vote: &Vote{Options: []WeightedOption{
+			{Option: "VOTE_OPTION_NO", Weight: "0.5"},
+		}}}}, []PeerVoteSummary{
+			{Option: "No", Validators: 1, PowerShare: 0.3},
+		}},
+		{"invalid weights split evenly", []PeerVote{{PowerShare: 0.4, Vote: &Vote{Options: []WeightedOption{
+			{Option: "VOTE_OPTION_YES", Weight: "half"},
+			{Option: "VOTE_OPTION_NO", Weight: "half"},
+		}}}}, []PeerVoteSummary{
+			{Option: "Yes", Validators: 0.5, PowerShare: 0.2},
+			{Option: "No", Validators: 0.5, PowerShare: 0.2},
+		}},
+		{"mixed votes ordered by power", []PeerVote{notVoted, failed, yes, split}, []PeerVoteSummary{
+			{Option: "Yes", Validators: 1.7, PowerShare: 0.58},
+			{Option: PeerVoteUnknown, Validators: 1, PowerShare: 0.2},
+			{Option: "Abstain", Validators: 0.3, PowerShare: 0.12},
+			{Option: PeerNotVoted, Validators: 1, PowerShare: 0.1},
+		}},
+		{"no validators", nil, []PeerVoteSummary{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summaries := SummarizePeerVotes(test.peerVotes)
+			if len(summaries) != len(test.expected) {
+				t.Fatalf("SummarizePeerVotes() = %+v, want %+v", summaries, test.expected)
+			}
+			for i, summary := range summaries {
+				expected := test.expected[i]
+				if summary.Option != expected.Option || summary.Validators != expected.Validators || math.Abs(summary.PowerShare-expected.PowerShare) > 1e-9 {
+					t.Errorf("SummarizePeerVotes()[%d] = %+v, want %+v", i, summary, expected)
+				}
+			}
+		})
+	}
+}
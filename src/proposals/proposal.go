@@ -1,10 +1,11 @@
 package proposals
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"tendermint_proposal_monitor/chainclient"
 	"tendermint_proposal_monitor/config"
 )
 
@@ -16,66 +17,135 @@ type Proposal struct {
 	Description     string `json:"description"`
 	VotingStartTime string `json:"voting_start_time"`
 	VotingEndTime   string `json:"voting_end_time"`
+	Expedited       bool   `json:"expedited"`
+	// Source is empty for x/gov proposals and names the module or contract of any other proposal
+	Source string `json:"source,omitempty"`
+	// DetailURL overrides the explorer link of the chain, "-" meaning there is none
+	DetailURL string `json:"detail_url,omitempty"`
+	// Upgrade is set for proposals scheduling a software upgrade
+	Upgrade *UpgradePlan `json:"upgrade,omitempty"`
+	// ChangesGovParams is set for proposals changing the governance parameters
+	ChangesGovParams bool `json:"changes_gov_params,omitempty"`
 }
 
-// ProposalV1 represents the structure for v1 API responses
+// ProposalV1 represents the structure for v1 API responses. Proposals can carry messages of any
+// module, so messages are decoded one at a time by decodeMessages.
 type ProposalV1 struct {
-	ID       string `json:"id"`
-	Status   string `json:"status"`
-	Messages []struct {
-		Content struct {
-			Title       string `json:"title"`
-			Description string `json:"description"`
-		} `json:"content"`
-	} `json:"messages"`
-	VotingStartTime string `json:"voting_start_time"`
-	VotingEndTime   string `json:"voting_end_time"`
+	ID              string            `json:"id"`
+	Status          string            `json:"status"`
+	Messages        []json.RawMessage `json:"messages"`
+	VotingStartTime string            `json:"voting_start_time"`
+	VotingEndTime   string            `json:"voting_end_time"`
+	// Expedited is only set by SDK 0.50 and later
+	Expedited bool `json:"expedited"`
 }
 
 // ProposalV1Beta1 represents the structure for v1beta1 API responses
 type ProposalV1Beta1 struct {
-	ProposalID string `json:"proposal_id"`
-	Status     string `json:"status"`
-	Content    struct {
+	ProposalID      string        `json:"proposal_id"`
+	Status          string        `json:"status"`
+	Content         legacyContent `json:"content"`
+	VotingStartTime string        `json:"voting_start_time"`
+	VotingEndTime   string        `json:"voting_end_time"`
+}
+
+// legacyContent is the content of a v1beta1 proposal, which v1 proposals execute through
+// MsgExecLegacyContent
+type legacyContent struct {
+	Type        string `json:"@type"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Plan and Changes are only read from the content types they belong to
+	Plan    *UpgradePlan  `json:"-"`
+	Changes []paramChange `json:"-"`
+}
+
+// UnmarshalJSON reads the plan and changes of the content only for the types that define them, so
+// content of other types with fields of the same name but another shape doesn't fail to decode
+func (c *legacyContent) UnmarshalJSON(data []byte) error {
+	var base struct {
+		Type        string `json:"@type"`
 		Title       string `json:"title"`
 		Description string `json:"description"`
-	} `json:"content"`
-	VotingStartTime string `json:"voting_start_time"`
-	VotingEndTime   string `json:"voting_end_time"`
+	}
+	err := json.Unmarshal(data, &base)
+	if err != nil {
+		return err
+	}
+	*c = legacyContent{Type: base.Type, Title: base.Title, Description: base.Description}
+
+	switch base.Type {
+	case TypeSoftwareUpgradeProposal:
+		var content struct {
+			Plan *UpgradePlan `json:"plan"`
+		}
+		if json.Unmarshal(data, &content) == nil {
+			c.Plan = content.Plan
+		}
+	case TypeParameterChangeProposal:
+		var content struct {
+			Changes []paramChange `json:"changes"`
+		}
+		if json.Unmarshal(data, &content) == nil {
+			c.Changes = content.Changes
+		}
+	}
+	return nil
+}
+
+// proposalMessage is a message of a v1 proposal, with the fields read from the message types that
+// carry them
+type proposalMessage struct {
+	Type    string
+	Plan    *UpgradePlan
+	Content legacyContent
 }
 
-func mockProposals() []Proposal {
-	return []Proposal{
-		{
-			ProposalID:      "44",
-			Status:          "PROPOSAL_STATUS_VOTING_PERIOD",
-			Title:           "Governance Community Spend Guardrails",
-			Description:     "Introduction: As a community, it is important to ensure that we have a way to control community.",
-			VotingStartTime: "2024-05-15T00:00:00.725539835Z",
-			VotingEndTime:   "2024-05-18T00:00:00.725539835Z",
-		},
+// decodeMessages decodes the messages of a v1 proposal one at a time, reading a plan only from
+// MsgSoftwareUpgrade and content only from MsgExecLegacyContent. A message that doesn't decode is
+// kept without its fields rather than failing the proposal list of the chain.
+func decodeMessages(raw []json.RawMessage) []proposalMessage {
+	messages := make([]proposalMessage, 0, len(raw))
+	for _, data := range raw {
+		var message proposalMessage
+		var header struct {
+			Type string `json:"@type"`
+		}
+		if json.Unmarshal(data, &header) == nil {
+			message.Type = header.Type
+		}
+
+		switch message.Type {
+		case TypeMsgSoftwareUpgrade:
+			var msg struct {
+				Plan *UpgradePlan `json:"plan"`
+			}
+			if json.Unmarshal(data, &msg) == nil {
+				message.Plan = msg.Plan
+			}
+		case TypeMsgExecLegacyContent, TypeMsgExecLegacyContentBeta:
+			var msg struct {
+				Content legacyContent `json:"content"`
+			}
+			if json.Unmarshal(data, &msg) == nil {
+				message.Content = msg.Content
+			}
+		}
+		messages = append(messages, message)
 	}
+	return messages
 }
 
-func Fetch(chain config.ChainConfig, sdkVersion string, useMock bool) ([]Proposal, error) {
+func Fetch(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, sdkVersion string) ([]Proposal, error) {
 	apiEndpoint := fmt.Sprintf("%s/cosmos/gov/%s/proposals?pagination.reverse=true", chain.APIEndpoint, chain.APIVersion)
-	if useMock {
-		return mockProposals(), nil
-	}
 
-	resp, err := http.Get(apiEndpoint)
+	body, statusCode, err := client.Get(ctx, apiEndpoint)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch proposals: %s", resp.Status)
-	}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch proposals: %d %s", statusCode, http.StatusText(statusCode))
 	}
 
 	switch sdkVersion {
@@ -111,20 +181,24 @@ func mapProposalsV1(proposals []ProposalV1) []Proposal {
 			title := "No Title"
 			description := "No Description"
 
-			if len(p.Messages) > 0 && p.Messages[0].Content.Title != "" {
-				title = p.Messages[0].Content.Title
+			messages := decodeMessages(p.Messages)
+			if messages[0].Content.Title != "" {
+				title = messages[0].Content.Title
 			}
-			if len(p.Messages) > 0 && p.Messages[0].Content.Description != "" {
-				description = p.Messages[0].Content.Description
+			if messages[0].Content.Description != "" {
+				description = messages[0].Content.Description
 			}
 
 			mapped = append(mapped, Proposal{
-				ProposalID:      p.ID,
-				Status:          p.Status,
-				Title:           title,
-				Description:     description,
-				VotingStartTime: p.VotingStartTime,
-				VotingEndTime:   p.VotingEndTime,
+				ProposalID:       p.ID,
+				Status:           p.Status,
+				Title:            title,
+				Description:      description,
+				VotingStartTime:  p.VotingStartTime,
+				VotingEndTime:    p.VotingEndTime,
+				Expedited:        p.Expedited,
+				Upgrade:          upgradePlanV1(messages),
+				ChangesGovParams: changesGovParamsV1(messages),
 			})
 		} else {
 			mapped = append(mapped, Proposal{
@@ -134,6 +208,7 @@ func mapProposalsV1(proposals []ProposalV1) []Proposal {
 				Description:     "No Description",
 				VotingStartTime: p.VotingStartTime,
 				VotingEndTime:   p.VotingEndTime,
+				Expedited:       p.Expedited,
 			})
 		}
 	}
@@ -144,12 +219,14 @@ func mapProposalsV1Beta1(proposals []ProposalV1Beta1) []Proposal {
 	var mapped []Proposal
 	for _, p := range proposals {
 		mapped = append(mapped, Proposal{
-			ProposalID:      p.ProposalID,
-			Status:          p.Status,
-			Title:           p.Content.Title,
-			Description:     p.Content.Description,
-			VotingStartTime: p.VotingStartTime,
-			VotingEndTime:   p.VotingEndTime,
+			ProposalID:       p.ProposalID,
+			Status:           p.Status,
+			Title:            p.Content.Title,
+			Description:      p.Content.Description,
+			VotingStartTime:  p.VotingStartTime,
+			VotingEndTime:    p.VotingEndTime,
+			Upgrade:          upgradePlan(p.Content.Type, p.Content.Plan),
+			ChangesGovParams: changesGovParams(p.Content.Type, p.Content.Changes),
 		})
 	}
 	return mapped
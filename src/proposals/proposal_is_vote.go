@@ -1,9 +1,11 @@
 package proposals
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"tendermint_proposal_monitor/chainclient"
 	"tendermint_proposal_monitor/config"
 )
 
@@ -25,44 +27,45 @@ type VoteResponseV1Beta1 struct {
 	Vote struct {
 		ProposalID string       `json:"proposal_id"`
 		Voter      string       `json:"voter"`
+		Option     string       `json:"option"`
 		Options    []VoteOption `json:"options"`
 	} `json:"vote"`
 }
 
-func CheckValidatorVoted(chain config.ChainConfig, proposalID string, validatorAddress string, sdkVersion string) (bool, error) {
+// FetchValidatorVote returns the vote of the address on the proposal, or nil if it hasn't voted
+func FetchValidatorVote(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, proposalID string, validatorAddress string, sdkVersion string) (*Vote, error) {
 	voteCheckURL := fmt.Sprintf("%s/cosmos/gov/%s/proposals/%s/votes/%s", chain.APIEndpoint, sdkVersion, proposalID, validatorAddress)
-	resp, err := http.Get(voteCheckURL)
+	body, statusCode, err := client.Get(ctx, voteCheckURL)
 	if err != nil {
-		return false, fmt.Errorf("error fetching vote status for proposal %s: %w", proposalID, err)
+		return nil, fmt.Errorf("%w: error fetching vote status for proposal %s: %v", ErrVoteStatusTransient, proposalID, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return false, nil
+	if statusCode != http.StatusOK {
+		return nil, classifyVoteResponse(proposalID, statusCode, body)
 	}
 
 	switch sdkVersion {
 	case "v1":
 		var voteResponse VoteResponseV1
-		err = json.NewDecoder(resp.Body).Decode(&voteResponse)
+		err = json.Unmarshal(body, &voteResponse)
 		if err != nil {
-			return false, fmt.Errorf("error decoding vote response for proposal %s: %w", proposalID, err)
+			return nil, fmt.Errorf("error decoding vote response for proposal %s: %w", proposalID, err)
 		}
 		if voteResponse.Vote.Voter == validatorAddress {
-			return true, nil
+			return newVote(validatorAddress, voteResponse.Vote.Options, "", voteResponse.Vote.Metadata), nil
 		}
 	case "v1beta1":
 		var voteResponse VoteResponseV1Beta1
-		err = json.NewDecoder(resp.Body).Decode(&voteResponse)
+		err = json.Unmarshal(body, &voteResponse)
 		if err != nil {
-			return false, fmt.Errorf("error decoding vote response for proposal %s: %w", proposalID, err)
+			return nil, fmt.Errorf("error decoding vote response for proposal %s: %w", proposalID, err)
 		}
 		if voteResponse.Vote.Voter == validatorAddress {
-			return true, nil
+			return newVote(validatorAddress, voteResponse.Vote.Options, voteResponse.Vote.Option, ""), nil
 		}
 	default:
-		return false, fmt.Errorf("unsupported sdk version: %s", sdkVersion)
+		return nil, fmt.Errorf("unsupported sdk version: %s", sdkVersion)
 	}
 
-	return false, nil
+	return nil, nil
 }
@@ -0,0 +1,68 @@
+package proposals
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFetchV1Messages(t *testing.T) {
+	response := `{"proposals":[
+		{"id":"4","status":"PROPOSAL_STATUS_VOTING_PERIOD","messages":[
+			{"@type":"/cosmwasm.wasm.v1.MsgExecuteContract","sender":"cosmos1gov","contract":"cosmos1contract","plan":"weekly","changes":{"fee":"1"},"content":"raw"}]},
+		{"id":"3","status":"PROPOSAL_STATUS_VOTING_PERIOD","messages":[
+			{"@type":"/cosmos.upgrade.v1beta1.MsgSoftwareUpgrade","authority":"cosmos1gov","plan":{"name":"v15","height":"1000","info":""}}]},
+		{"id":"2","status":"PROPOSAL_STATUS_VOTING_PERIOD","messages":[
+			{"@type":"/cosmos.gov.v1.MsgExecLegacyContent","authority":"cosmos1gov","content":{"@type":"/cosmos.upgrade.v1beta1.SoftwareUpgradeProposal","title":"Upgrade","description":"To v14","plan":{"name":"v14","height":"900","info":""}}}]},
+		{"id":"1","status":"PROPOSAL_STATUS_PASSED","messages":[
+			{"@type":"/cosmos.gov.v1.MsgExecLegacyContent","authority":"cosmos1gov","content":{"@type":"/cosmos.params.v1beta1.ParameterChangeProposal","title":"Params","description":"Shorter voting","changes":[{"subspace":"gov","key":"votingparams","value":"{}"}]}}]}
+	]}`
+	client, chain := newTestChain(t, serveResponses(map[string]string{"/cosmos/gov/v1/proposals": response}))
+	chain.APIVersion = "v1"
+
+	propList, err := Fetch(context.Background(), client, chain, "v1")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(propList) != 4 {
+		t.Fatalf("fetched %d proposals, want 4", len(propList))
+	}
+
+	byID := make(map[string]Proposal)
+	for _, proposal := range propList {
+		byID[proposal.ProposalID] = proposal
+	}
+	if byID["4"].Upgrade != nil || byID["4"].ChangesGovParams || byID["4"].Title != "No Title" {
+		t.Errorf("contract message read as %+v, want no upgrade, params change or title", byID["4"])
+	}
+	if upgrade := byID["3"].Upgrade; upgrade == nil || upgrade.Name != "v15" || upgrade.Height != 1000 {
+		t.Errorf("upgrade of MsgSoftwareUpgrade = %+v, want v15 at 1000", upgrade)
+	}
+	if upgrade := byID["2"].Upgrade; upgrade == nil || upgrade.Name != "v14" || byID["2"].Title != "Upgrade" {
+		t.Errorf("legacy upgrade proposal read as %q with upgrade %+v, want Upgrade to v14", byID["2"].Title, upgrade)
+	}
+	if !byID["1"].ChangesGovParams {
+		t.Errorf("legacy gov params change not recognized")
+	}
+}
+
+func TestFetchV1Beta1Content(t *testing.T) {
+	response := `{"proposals":[
+		{"proposal_id":"2","status":"PROPOSAL_STATUS_VOTING_PERIOD","content":{"@type":"/osmosis.poolincentives.v1beta1.UpdatePoolIncentivesProposal","title":"Incentives","description":"","plan":"weekly","changes":{"pool":"1"}}},
+		{"proposal_id":"1","status":"PROPOSAL_STATUS_VOTING_PERIOD","content":{"@type":"/cosmos.upgrade.v1beta1.SoftwareUpgradeProposal","title":"Upgrade","description":"","plan":{"name":"v14","height":"900","info":""}}}
+	]}`
+	client, chain := newTestChain(t, serveResponses(map[string]string{"/cosmos/gov/v1beta1/proposals": response}))
+
+	propList, err := Fetch(context.Background(), client, chain, "v1beta1")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(propList) != 2 {
+		t.Fatalf("fetched %d proposals, want 2", len(propList))
+	}
+	if propList[0].Title != "Incentives" || propList[0].Upgrade != nil {
+		t.Errorf("content of another type read as %q with upgrade %+v, want Incentives without upgrade", propList[0].Title, propList[0].Upgrade)
+	}
+	if upgrade := propList[1].Upgrade; upgrade == nil || upgrade.Name != "v14" {
+		t.Errorf("upgrade of legacy proposal = %+v, want v14", upgrade)
+	}
+}
@@ -0,0 +1,96 @@
+package proposals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// Governance implementations a chain's proposals can be read from, selected with proposal_source
+const (
+	ProposalSourceCosmos = "cosmos"
+	ProposalSourceNamada = "namada"
+)
+
+// ProposalSource reads the on-chain governance of a chain. Chains built on the Cosmos SDK use
+// CosmosSource; other CometBFT chains with their own governance plug in their own implementation.
+type ProposalSource interface {
+	ListProposals(ctx context.Context) ([]Proposal, error)
+	// GetVote returns how the voter voted on the proposal, or nil if it hasn't voted
+	GetVote(ctx context.Context, proposalID string, voter string) (*Vote, error)
+	Tally(ctx context.Context, proposalID string) (*Tally, error)
+}
+
+// NewProposalSource returns the source configured for the chain, the Cosmos SDK REST API by default
+func NewProposalSource(client *chainclient.Client, chainName string, chain config.ChainConfig) (ProposalSource, error) {
+	switch chain.ProposalSource {
+	case "", ProposalSourceCosmos:
+		return &CosmosSource{Client: client, ChainName: chainName, Chain: chain}, nil
+	case ProposalSourceNamada:
+		return &NamadaSource{Client: client, Chain: chain}, nil
+	default:
+		return nil, fmt.Errorf("unsupported proposal source: %s", chain.ProposalSource)
+	}
+}
+
+// IsCosmosSDK reports whether the chain is read through the Cosmos SDK REST API, which node health
+// checks, gov params, x/group and DAO DAO proposals depend on
+func IsCosmosSDK(chain config.ChainConfig) bool {
+	return chain.ProposalSource == "" || chain.ProposalSource == ProposalSourceCosmos
+}
+
+// CosmosSource reads x/gov proposals through the Cosmos SDK REST API
+type CosmosSource struct {
+	Client    *chainclient.Client
+	ChainName string
+	Chain     config.ChainConfig
+}
+
+func (s *CosmosSource) ListProposals(ctx context.Context) ([]Proposal, error) {
+	propList, err := Fetch(ctx, s.Client, s.Chain, s.Chain.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upgrade estimates are refreshed on every run, as the average block time drifts
+	err = EstimateUpgradeTimes(ctx, s.Client, s.Chain, propList)
+	if err != nil {
+		log.Printf("Error estimating upgrade times for chain %s: %v", s.ChainName, err)
+	}
+	ParseUpgradeInfos(ctx, s.Client, propList)
+	return propList, nil
+}
+
+// GetVote asks the votes endpoint, then searches the transactions of the proposal for votes it
+// doesn't know of, or can't be asked about. When the search fails too, which it does on nodes that
+// don't index transactions, a missing vote is reported as ErrVoteUnconfirmed.
+func (s *CosmosSource) GetVote(ctx context.Context, proposalID string, voter string) (*Vote, error) {
+	vote, voteErr := FetchValidatorVote(ctx, s.Client, s.Chain, proposalID, voter, s.Chain.APIVersion)
+	if vote != nil || errors.Is(voteErr, ErrVoteStatusTransient) {
+		return vote, voteErr
+	}
+
+	tx, err := FindVoteTx(ctx, s.Client, s.Chain, proposalID, voter)
+	if err != nil {
+		log.Printf("Error searching vote transactions of %s for proposal %s on chain %s: %v", voter, proposalID, s.ChainName, err)
+		if voteErr != nil {
+			return nil, voteErr
+		}
+		return nil, fmt.Errorf("%w for proposal %s: %v", ErrVoteUnconfirmed, proposalID, err)
+	}
+	if tx == nil {
+		return nil, voteErr
+	}
+	if tx.Grantee != "" {
+		log.Printf("Found vote of %s for proposal %s on chain %s cast by grantee %s in tx %s", voter, proposalID, s.ChainName, tx.Grantee, tx.TxHash)
+	}
+	return tx.Vote, nil
+}
+
+func (s *CosmosSource) Tally(ctx context.Context, proposalID string) (*Tally, error) {
+	return FetchTally(ctx, s.Client, s.Chain, proposalID)
+}
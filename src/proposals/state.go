@@ -3,6 +3,7 @@ package proposals
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"tendermint_proposal_monitor/config"
 	"tendermint_proposal_monitor/utils"
 
@@ -24,8 +25,62 @@ const (
 	CollectionNameLastChecked      = "last_checked_proposals"
 	CollectionNameAlertedProposals = "alerted_proposals"
 	CollectionNameVotingEndAlerted = "voting_end_alerted_proposals"
+	CollectionNameSnapshots        = "proposal_snapshots"
+	CollectionNameEmittedEvents    = "emitted_events"
+	CollectionNameIncidents        = "missed_vote_incidents"
+	CollectionNameVoteHistory      = "vote_history"
 )
 
+// ProposalSnapshot is the last known state of a proposal, used to detect lifecycle changes between runs
+type ProposalSnapshot struct {
+	ProposalID    string `firestore:"proposal_id" json:"proposal_id"`
+	Status        string `firestore:"status" json:"status"`
+	VotingEndTime string `firestore:"voting_end_time" json:"voting_end_time"`
+	Voted         bool   `firestore:"voted" json:"voted"`
+	Expedited     bool   `firestore:"expedited" json:"expedited"`
+	// VotedBy lists the addresses that have voted, Voted being set once all voters of the source have
+	VotedBy []string `firestore:"voted_by" json:"voted_by,omitempty"`
+	// Votes holds how they voted, where it is known
+	Votes []Vote `firestore:"votes" json:"votes,omitempty"`
+	// VoteChanges counts the changes seen of each voter's vote, by address
+	VoteChanges map[string]int `firestore:"vote_changes" json:"vote_changes,omitempty"`
+	// Reminders lists the alerts sent about the proposal while it could be voted on
+	Reminders []Reminder `firestore:"reminders" json:"reminders,omitempty"`
+}
+
+// VoteOf returns how the address voted, or nil if that isn't known
+func (s ProposalSnapshot) VoteOf(address string) *Vote {
+	for i := range s.Votes {
+		if s.Votes[i].Voter == address {
+			return &s.Votes[i]
+		}
+	}
+	return nil
+}
+
+// HasVoted reports whether the snapshot records a vote of the address. Snapshots saved before votes
+// were tracked per address only record whether the single voter of the source voted.
+func (s ProposalSnapshot) HasVoted(address string) bool {
+	if s.VotedBy == nil {
+		return s.Voted
+	}
+	for _, voter := range s.VotedBy {
+		if voter == address {
+			return true
+		}
+	}
+	return false
+}
+
+type ChainSnapshots struct {
+	Key       string             `firestore:"key"`
+	Proposals []ProposalSnapshot `firestore:"proposals"`
+}
+
+type ProposalSnapshots struct {
+	Chains []ChainSnapshots `firestore:"chains"`
+}
+
 type FirestoreHandler struct {
 	FirestoreClient *firestore.Client
 	CredentialsFile string
@@ -54,6 +109,17 @@ func (c *FirestoreHandler) getFirestoreClient() *firestore.Client {
 	return c.FirestoreClient
 }
 
+// recordsCollection holds the records stored under the named document, one document per record, so
+// saving a record doesn't rewrite the others
+func (c *FirestoreHandler) recordsCollection(name string) *firestore.CollectionRef {
+	return c.getFirestoreClient().Collection(c.CollectionName).Doc(name).Collection("records")
+}
+
+// recordDocID turns the key of a record into a document ID, which can't contain slashes
+func recordDocID(key string) string {
+	return url.PathEscape(key)
+}
+
 func (c *FirestoreHandler) GetLastCheckedProposalIDs(ctx context.Context) (map[string]int, error) {
 	client := c.getFirestoreClient()
 
@@ -114,6 +180,52 @@ func (c *FirestoreHandler) SaveAlertedProposals(ctx context.Context, docID strin
 	return err
 }
 
+func (c *FirestoreHandler) GetProposalSnapshots(ctx context.Context) (map[string]map[string]ProposalSnapshot, error) {
+	client := c.getFirestoreClient()
+
+	doc := client.Collection(c.CollectionName).Doc(CollectionNameSnapshots)
+	var entity ProposalSnapshots
+	dsnap, err := doc.Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return make(map[string]map[string]ProposalSnapshot), nil
+		}
+		return nil, err
+	}
+
+	err = dsnap.DataTo(&entity)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[string]map[string]ProposalSnapshot)
+	for _, chain := range entity.Chains {
+		chainSnapshots := make(map[string]ProposalSnapshot)
+		for _, snapshot := range chain.Proposals {
+			chainSnapshots[snapshot.ProposalID] = snapshot
+		}
+		snapshots[chain.Key] = chainSnapshots
+	}
+	return snapshots, nil
+}
+
+func (c *FirestoreHandler) SaveProposalSnapshots(ctx context.Context, snapshots map[string]map[string]ProposalSnapshot) error {
+	client := c.getFirestoreClient()
+
+	var entity ProposalSnapshots
+	for chainName, chainSnapshots := range snapshots {
+		chain := ChainSnapshots{Key: chainName}
+		for _, snapshot := range chainSnapshots {
+			chain.Proposals = append(chain.Proposals, snapshot)
+		}
+		entity.Chains = append(entity.Chains, chain)
+	}
+
+	doc := client.Collection(c.CollectionName).Doc(CollectionNameSnapshots)
+	_, err := doc.Set(ctx, entity)
+	return err
+}
+
 func (c *FirestoreHandler) InitState() (map[string]int, map[string]map[string]bool, map[string]map[string]bool, error) {
 	ctx := context.Background()
 
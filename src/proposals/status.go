@@ -1,19 +1,50 @@
 package proposals
 
+import "strings"
+
+const (
+	ProposalStatusUnspecified   = "PROPOSAL_STATUS_UNSPECIFIED"
+	ProposalStatusDepositPeriod = "PROPOSAL_STATUS_DEPOSIT_PERIOD"
+	ProposalStatusVotingPeriod  = "PROPOSAL_STATUS_VOTING_PERIOD"
+	ProposalStatusPassed        = "PROPOSAL_STATUS_PASSED"
+	ProposalStatusRejected      = "PROPOSAL_STATUS_REJECTED"
+	ProposalStatusFailed        = "PROPOSAL_STATUS_FAILED"
+)
+
 var (
 	ProposalStatusName = map[int32]string{
-		0: "PROPOSAL_STATUS_UNSPECIFIED",
-		1: "PROPOSAL_STATUS_VOTING_PERIOD",
-		2: "PROPOSAL_STATUS_PASSED",
-		3: "PROPOSAL_STATUS_REJECTED",
-		4: "PROPOSAL_STATUS_FAILED",
+		0: ProposalStatusUnspecified,
+		1: ProposalStatusDepositPeriod,
+		2: ProposalStatusVotingPeriod,
+		3: ProposalStatusPassed,
+		4: ProposalStatusRejected,
+		5: ProposalStatusFailed,
 	}
 
 	ProposalStatusValue = map[string]int32{
-		"PROPOSAL_STATUS_UNSPECIFIED":   0,
-		"PROPOSAL_STATUS_VOTING_PERIOD": 1,
-		"PROPOSAL_STATUS_PASSED":        2,
-		"PROPOSAL_STATUS_REJECTED":      3,
-		"PROPOSAL_STATUS_FAILED":        4,
+		ProposalStatusUnspecified:   0,
+		ProposalStatusDepositPeriod: 1,
+		ProposalStatusVotingPeriod:  2,
+		ProposalStatusPassed:        3,
+		ProposalStatusRejected:      4,
+		ProposalStatusFailed:        5,
 	}
 )
+
+// IsFinalStatus reports whether a proposal with the given status can no longer change
+func IsFinalStatus(status string) bool {
+	switch status {
+	case ProposalStatusPassed, ProposalStatusRejected, ProposalStatusFailed:
+		return true
+	}
+	return false
+}
+
+// StatusLabel returns the status as shown in alerts, e.g. "Voting period" for PROPOSAL_STATUS_VOTING_PERIOD
+func StatusLabel(status string) string {
+	label := strings.ReplaceAll(strings.TrimPrefix(status, "PROPOSAL_STATUS_"), "_", " ")
+	if label == "" {
+		return label
+	}
+	return label[:1] + strings.ToLower(label[1:])
+}
@@ -0,0 +1,157 @@
+package proposals
+
+import (
+	"context"
+	"sync"
+)
+
+// Store persists the monitor state between runs. FirestoreHandler is the production store, and
+// MemoryStore keeps the state in process for mock runs.
+type Store interface {
+	InitState() (map[string]int, map[string]map[string]bool, map[string]map[string]bool, error)
+	SaveLastCheckedProposalIDs(ctx context.Context, docID string, lastChecked map[string]int) error
+	GetAlertedProposals(ctx context.Context, docID string) (map[string]map[string]bool, error)
+	SaveAlertedProposals(ctx context.Context, docID string, alertedProposals map[string]map[string]bool) error
+	GetProposalSnapshots(ctx context.Context) (map[string]map[string]ProposalSnapshot, error)
+	SaveProposalSnapshots(ctx context.Context, snapshots map[string]map[string]ProposalSnapshot) error
+	GetEmittedEvents(ctx context.Context) (map[string]map[string]bool, error)
+	SaveEmittedEvent(ctx context.Context, event EmittedEvent) error
+	GetIncidents(ctx context.Context) ([]Incident, error)
+	SaveIncident(ctx context.Context, incident Incident) error
+	GetVoteHistory(ctx context.Context) ([]VoteRecord, error)
+	SaveVoteRecord(ctx context.Context, record VoteRecord) error
+}
+
+// MemoryStore keeps the state of the monitor in memory, so consecutive mock runs behave like
+// consecutive production runs without touching Firestore
+type MemoryStore struct {
+	mu          sync.Mutex
+	lastChecked map[string]int
+	alerted     map[string]map[string]map[string]bool
+	snapshots   map[string]map[string]ProposalSnapshot
+	emitted     map[string]map[string]bool
+	incidents   []Incident
+	history     []VoteRecord
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		lastChecked: make(map[string]int),
+		alerted:     make(map[string]map[string]map[string]bool),
+		snapshots:   make(map[string]map[string]ProposalSnapshot),
+		emitted:     make(map[string]map[string]bool),
+	}
+}
+
+func (m *MemoryStore) InitState() (map[string]int, map[string]map[string]bool, map[string]map[string]bool, error) {
+	ctx := context.Background()
+
+	m.mu.Lock()
+	lastChecked := make(map[string]int, len(m.lastChecked))
+	for key, id := range m.lastChecked {
+		lastChecked[key] = id
+	}
+	m.mu.Unlock()
+
+	alertedProposals, _ := m.GetAlertedProposals(ctx, CollectionNameAlertedProposals)
+	votingEndAlertedProposals, _ := m.GetAlertedProposals(ctx, CollectionNameVotingEndAlerted)
+	return lastChecked, alertedProposals, votingEndAlertedProposals, nil
+}
+
+func (m *MemoryStore) SaveLastCheckedProposalIDs(ctx context.Context, docID string, lastChecked map[string]int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastChecked = make(map[string]int, len(lastChecked))
+	for key, id := range lastChecked {
+		m.lastChecked[key] = id
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetAlertedProposals(ctx context.Context, docID string) (map[string]map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return copyNested(m.alerted[docID]), nil
+}
+
+func (m *MemoryStore) SaveAlertedProposals(ctx context.Context, docID string, alertedProposals map[string]map[string]bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alerted[docID] = copyNested(alertedProposals)
+	return nil
+}
+
+func (m *MemoryStore) GetProposalSnapshots(ctx context.Context) (map[string]map[string]ProposalSnapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return copySnapshots(m.snapshots), nil
+}
+
+func (m *MemoryStore) SaveProposalSnapshots(ctx context.Context, snapshots map[string]map[string]ProposalSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshots = copySnapshots(snapshots)
+	return nil
+}
+
+func (m *MemoryStore) GetEmittedEvents(ctx context.Context) (map[string]map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return copyNested(m.emitted), nil
+}
+
+func (m *MemoryStore) SaveEmittedEvent(ctx context.Context, event EmittedEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	withEmittedEvent(m.emitted, event)
+	return nil
+}
+
+func (m *MemoryStore) GetIncidents(ctx context.Context) ([]Incident, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Incident(nil), m.incidents...), nil
+}
+
+func (m *MemoryStore) SaveIncident(ctx context.Context, incident Incident) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.incidents = withIncident(m.incidents, incident)
+	return nil
+}
+
+func (m *MemoryStore) GetVoteHistory(ctx context.Context) ([]VoteRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]VoteRecord(nil), m.history...), nil
+}
+
+func (m *MemoryStore) SaveVoteRecord(ctx context.Context, record VoteRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = withVoteRecord(m.history, record)
+	return nil
+}
+
+// The maps are copied both ways, since the monitor keeps modifying the ones it loaded during a run
+func copyNested(source map[string]map[string]bool) map[string]map[string]bool {
+	copied := make(map[string]map[string]bool, len(source))
+	for outer, inner := range source {
+		copied[outer] = make(map[string]bool, len(inner))
+		for key, value := range inner {
+			copied[outer][key] = value
+		}
+	}
+	return copied
+}
+
+func copySnapshots(source map[string]map[string]ProposalSnapshot) map[string]map[string]ProposalSnapshot {
+	copied := make(map[string]map[string]ProposalSnapshot, len(source))
+	for key, chainSnapshots := range source {
+		copied[key] = make(map[string]ProposalSnapshot, len(chainSnapshots))
+		for id, snapshot := range chainSnapshots {
+			copied[key][id] = snapshot
+		}
+	}
+	return copied
+}
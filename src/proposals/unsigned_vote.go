@@ -0,0 +1,274 @@
+package proposals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// DefaultVoteGasLimit is the gas limit of prepared vote transactions when none is given, enough for a
+// MsgExec executing a vote
+const DefaultVoteGasLimit = 300000
+
+// VoteTxFile is the file the sign command of a prepared vote reads the transaction from
+const VoteTxFile = "vote-tx.json"
+
+// DefaultChainBinary stands in for the chain daemon in sign commands when the chain doesn't set binary
+const DefaultChainBinary = "<chaind>"
+
+// voteOptionValues maps the options accepted when preparing a vote onto the gov module's enum
+var voteOptionValues = map[string]string{
+	"yes":          "VOTE_OPTION_YES",
+	"abstain":      "VOTE_OPTION_ABSTAIN",
+	"no":           "VOTE_OPTION_NO",
+	"no_with_veto": "VOTE_OPTION_NO_WITH_VETO",
+	"veto":         "VOTE_OPTION_NO_WITH_VETO",
+}
+
+// ParseVoteOption accepts yes, no, abstain, no_with_veto or veto, or the enum name such as
+// VOTE_OPTION_YES
+func ParseVoteOption(option string) (string, error) {
+	normalized := strings.ToLower(strings.TrimPrefix(strings.ToUpper(option), "VOTE_OPTION_"))
+	if value, ok := voteOptionValues[normalized]; ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid vote option %q, expected yes, no, abstain or no_with_veto", option)
+}
+
+// UnsignedTx is a transaction in the JSON format of the chain CLI's --generate-only output, which
+// `<chaind> tx sign` takes
+type UnsignedTx struct {
+	Body struct {
+		Messages                    []map[string]interface{} `json:"messages"`
+		Memo                        string                   `json:"memo"`
+		TimeoutHeight               string                   `json:"timeout_height"`
+		ExtensionOptions            []interface{}            `json:"extension_options"`
+		NonCriticalExtensionOptions []interface{}            `json:"non_critical_extension_options"`
+	} `json:"body"`
+	AuthInfo struct {
+		SignerInfos []interface{} `json:"signer_infos"`
+		Fee         struct {
+			Amount   []Coin `json:"amount"`
+			GasLimit string `json:"gas_limit"`
+			Payer    string `json:"payer"`
+			Granter  string `json:"granter"`
+		} `json:"fee"`
+	} `json:"auth_info"`
+	Signatures []string `json:"signatures"`
+}
+
+// UnsignedVoteTx is a vote transaction ready to be signed offline, with the account number and
+// sequence of its signer
+type UnsignedVoteTx struct {
+	ChainID       string     `json:"chain_id"`
+	ProposalID    string     `json:"proposal_id"`
+	Voter         string     `json:"voter"`
+	Signer        string     `json:"signer"`
+	AccountNumber string     `json:"account_number"`
+	Sequence      string     `json:"sequence"`
+	Tx            UnsignedTx `json:"tx"`
+	SignCommand   string     `json:"sign_command"`
+}
+
+// VoteTxOptions are the choices made when preparing a vote transaction. Voter defaults to the first
+// voter of the chain, and Grantee, which signs a MsgExec for the voter when set, to its grantee.
+type VoteTxOptions struct {
+	ProposalID string
+	Option     string
+	Voter      string
+	Grantee    string
+	GasLimit   uint64
+	Fees       []Coin
+	Memo       string
+}
+
+// BuildUnsignedVoteTx prepares a MsgVote of the voter, or a MsgExec executing it for authz setups,
+// filling in the account number and sequence of the signer from the auth module
+func BuildUnsignedVoteTx(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, opts VoteTxOptions) (*UnsignedVoteTx, error) {
+	option, err := ParseVoteOption(opts.Option)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ProposalID == "" {
+		return nil, fmt.Errorf("missing proposal ID")
+	}
+
+	voter, grantee := opts.Voter, opts.Grantee
+	voters := chain.VoterList()
+	if voter == "" {
+		if len(voters) == 0 {
+			return nil, fmt.Errorf("no voter configured for the chain")
+		}
+		voter = voters[0].Address
+	}
+	voter, err = voterAccount(voter, chain)
+	if err != nil {
+		return nil, err
+	}
+	// Configured voters are matched by the account they vote from, which is what alerts link with
+	for _, configured := range voters {
+		if account, err := voterAccount(configured.Address, chain); grantee == "" && err == nil && account == voter {
+			grantee = configured.Grantee
+		}
+	}
+
+	msgType := TypeMsgVoteV1Beta1
+	if chain.APIVersion == "v1" {
+		msgType = TypeMsgVoteV1
+	}
+	msg := map[string]interface{}{
+		"@type":       msgType,
+		"proposal_id": opts.ProposalID,
+		"voter":       voter,
+		"option":      option,
+	}
+	if msgType == TypeMsgVoteV1 {
+		msg["metadata"] = ""
+	}
+
+	signer := voter
+	if grantee != "" && grantee != voter {
+		signer = grantee
+		msg = map[string]interface{}{
+			"@type":   TypeMsgExec,
+			"grantee": grantee,
+			"msgs":    []map[string]interface{}{msg},
+		}
+	}
+
+	accountNumber, sequence, err := FetchAccountSequence(ctx, client, chain, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		gasLimit = DefaultVoteGasLimit
+	}
+	voteTx := &UnsignedVoteTx{
+		ChainID:       chain.ChainID,
+		ProposalID:    opts.ProposalID,
+		Voter:         voter,
+		Signer:        signer,
+		AccountNumber: accountNumber,
+		Sequence:      sequence,
+	}
+	voteTx.Tx.Body.Messages = []map[string]interface{}{msg}
+	voteTx.Tx.Body.Memo = opts.Memo
+	voteTx.Tx.Body.TimeoutHeight = "0"
+	voteTx.Tx.Body.ExtensionOptions = []interface{}{}
+	voteTx.Tx.Body.NonCriticalExtensionOptions = []interface{}{}
+	voteTx.Tx.AuthInfo.SignerInfos = []interface{}{}
+	voteTx.Tx.AuthInfo.Fee.Amount = append([]Coin{}, opts.Fees...)
+	voteTx.Tx.AuthInfo.Fee.GasLimit = strconv.FormatUint(gasLimit, 10)
+	voteTx.Tx.Signatures = []string{}
+
+	binary := chain.Binary
+	if binary == "" {
+		binary = DefaultChainBinary
+	}
+	voteTx.SignCommand = fmt.Sprintf("%s tx sign %s --from %s --chain-id %s --offline --account-number %s --sequence %s --output-document signed-vote-tx.json",
+		binary, VoteTxFile, signer, chain.ChainID, accountNumber, sequence)
+	return voteTx, nil
+}
+
+// IsConfiguredVoter reports whether the address is one of the voters configured for the chain, matched
+// by the account it votes from. An empty address stands for the first configured voter.
+func IsConfiguredVoter(chain config.ChainConfig, address string) bool {
+	voters := chain.VoterList()
+	if address == "" {
+		return len(voters) > 0
+	}
+	account, err := voterAccount(address, chain)
+	if err != nil {
+		return false
+	}
+	for _, configured := range voters {
+		if configuredAccount, err := voterAccount(configured.Address, chain); err == nil && configuredAccount == account {
+			return true
+		}
+	}
+	return false
+}
+
+func voterAccount(address string, chain config.ChainConfig) (string, error) {
+	if !IsValoperAddress(address) {
+		return address, nil
+	}
+	account, err := AccountAddress(address, chain.AccountPrefix)
+	if err != nil {
+		return "", fmt.Errorf("error converting validator address: %v", err)
+	}
+	return account, nil
+}
+
+// FetchAccountSequence returns the account number and sequence of the address. Vesting and other
+// account types embedding a base account are supported.
+func FetchAccountSequence(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, address string) (string, string, error) {
+	var resp struct {
+		Account json.RawMessage `json:"account"`
+	}
+	err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/auth/v1beta1/accounts/%s", chain.APIEndpoint, address), &resp)
+	if err != nil {
+		return "", "", fmt.Errorf("error fetching account %s: %v", address, err)
+	}
+
+	accountNumber, sequence, ok := accountSequence(resp.Account)
+	if !ok {
+		return "", "", fmt.Errorf("no account number found for account %s", address)
+	}
+	return accountNumber, sequence, nil
+}
+
+func accountSequence(account json.RawMessage) (string, string, bool) {
+	var fields struct {
+		AccountNumber      string          `json:"account_number"`
+		Sequence           string          `json:"sequence"`
+		BaseAccount        json.RawMessage `json:"base_account"`
+		BaseVestingAccount json.RawMessage `json:"base_vesting_account"`
+	}
+	if json.Unmarshal(account, &fields) != nil {
+		return "", "", false
+	}
+	if fields.AccountNumber != "" {
+		// A sequence of zero is left out by some chains
+		if fields.Sequence == "" {
+			fields.Sequence = "0"
+		}
+		return fields.AccountNumber, fields.Sequence, true
+	}
+	for _, nested := range []json.RawMessage{fields.BaseAccount, fields.BaseVestingAccount} {
+		if nested == nil {
+			continue
+		}
+		if accountNumber, sequence, ok := accountSequence(nested); ok {
+			return accountNumber, sequence, true
+		}
+	}
+	return "", "", false
+}
+
+var coinPattern = regexp.MustCompile(`^([0-9]+)([a-zA-Z][a-zA-Z0-9/:._-]*)$`)
+
+// ParseCoins parses fees given as the chain CLI takes them, such as 5000uatom or 10uatom,20ibc/ABC
+func ParseCoins(value string) ([]Coin, error) {
+	var coins []Coin
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		match := coinPattern.FindStringSubmatch(part)
+		if match == nil {
+			return nil, fmt.Errorf("invalid coin %q", part)
+		}
+		coins = append(coins, Coin{Denom: match[2], Amount: match[1]})
+	}
+	return coins, nil
+}
@@ -0,0 +1,188 @@
+package proposals
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tendermint_proposal_monitor/config"
+)
+
+const testGrantee = "cosmos1grantee"
+
+func TestBuildUnsignedVoteTx(t *testing.T) {
+	accounts := map[string]string{
+		"/cosmos/auth/v1beta1/accounts/" + testVoter:   `{"account":{"@type":"/cosmos.auth.v1beta1.BaseAccount","address":"cosmos1voter","account_number":"12","sequence":"7"}}`,
+		"/cosmos/auth/v1beta1/accounts/" + testGrantee: `{"account":{"@type":"/cosmos.auth.v1beta1.BaseAccount","address":"cosmos1grantee","account_number":"34","sequence":"2"}}`,
+	}
+	tests := []struct {
+		name          string
+		version       string
+		voters        []config.VoterConfig
+		opts          VoteTxOptions
+		msgType       string
+		signer        string
+		accountNumber string
+		sequence      string
+	}{
+		{
+			name:          "v1beta1 vote",
+			version:       "v1beta1",
+			voters:        []config.VoterConfig{{Address: testVoter}},
+			opts:          VoteTxOptions{ProposalID: "5", Option: "yes"},
+			msgType:       TypeMsgVoteV1Beta1,
+			signer:        testVoter,
+			accountNumber: "12",
+			sequence:      "7",
+		},
+		{
+			name:          "v1 vote",
+			version:       "v1",
+			voters:        []config.VoterConfig{{Address: testVoter}},
+			opts:          VoteTxOptions{ProposalID: "5", Option: "yes"},
+			msgType:       TypeMsgVoteV1,
+			signer:        testVoter,
+			accountNumber: "12",
+			sequence:      "7",
+		},
+		{
+			name:          "grantee of configured voter",
+			version:       "v1",
+			voters:        []config.VoterConfig{{Address: testVoter, Grantee: testGrantee}},
+			opts:          VoteTxOptions{ProposalID: "5", Option: "yes"},
+			msgType:       TypeMsgVoteV1,
+			signer:        testGrantee,
+			accountNumber: "34",
+			sequence:      "2",
+		},
+		{
+			name:          "grantee given",
+			version:       "v1beta1",
+			voters:        []config.VoterConfig{{Address: testVoter}},
+			opts:          VoteTxOptions{ProposalID: "5", Option: "yes", Grantee: testGrantee},
+			msgType:       TypeMsgVoteV1Beta1,
+			signer:        testGrantee,
+			accountNumber: "34",
+			sequence:      "2",
+		},
+		{
+			name:          "grantee same as voter",
+			version:       "v1",
+			voters:        []config.VoterConfig{{Address: testVoter, Grantee: testVoter}},
+			opts:          VoteTxOptions{ProposalID: "5", Option: "yes"},
+			msgType:       TypeMsgVoteV1,
+			signer:        testVoter,
+			accountNumber: "12",
+			sequence:      "7",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, chain := newTestChain(t, serveResponses(accounts))
+			chain.APIVersion = test.version
+			chain.ChainID = "cosmoshub-4"
+			chain.Voters = test.voters
+
+			voteTx, err := BuildUnsignedVoteTx(context.Background(), client, chain, test.opts)
+			if err != nil {
+				t.Fatalf("BuildUnsignedVoteTx() error = %v", err)
+			}
+			if voteTx.Voter != testVoter || voteTx.Signer != test.signer {
+				t.Errorf("BuildUnsignedVoteTx() voter %q, signer %q, want %q, %q", voteTx.Voter, voteTx.Signer, testVoter, test.signer)
+			}
+			if voteTx.AccountNumber != test.accountNumber || voteTx.Sequence != test.sequence {
+				t.Errorf("BuildUnsignedVoteTx() account number %q, sequence %q, want %q, %q", voteTx.AccountNumber, voteTx.Sequence, test.accountNumber, test.sequence)
+			}
+			if !strings.Contains(voteTx.SignCommand, "--from "+test.signer+" ") || !strings.Contains(voteTx.SignCommand, "--sequence "+test.sequence+" ") {
+				t.Errorf("BuildUnsignedVoteTx() sign command %q doesn't sign as %s with sequence %s", voteTx.SignCommand, test.signer, test.sequence)
+			}
+			if len(voteTx.Tx.Body.Messages) != 1 {
+				t.Fatalf("BuildUnsignedVoteTx() messages = %v, want one message", voteTx.Tx.Body.Messages)
+			}
+
+			msg := voteTx.Tx.Body.Messages[0]
+			if test.signer != testVoter {
+				if msg["@type"] != TypeMsgExec || msg["grantee"] != test.signer {
+					t.Fatalf("BuildUnsignedVoteTx() message = %v, want a MsgExec of %s", msg, test.signer)
+				}
+				msgs, _ := msg["msgs"].([]map[string]interface{})
+				if len(msgs) != 1 {
+					t.Fatalf("BuildUnsignedVoteTx() MsgExec messages = %v, want one vote", msg["msgs"])
+				}
+				msg = msgs[0]
+			}
+			if msg["@type"] != test.msgType || msg["voter"] != testVoter || msg["proposal_id"] != "5" || msg["option"] != "VOTE_OPTION_YES" {
+				t.Errorf("BuildUnsignedVoteTx() vote = %v, want a %s of %s", msg, test.msgType, testVoter)
+			}
+			if _, hasMetadata := msg["metadata"]; hasMetadata != (test.msgType == TypeMsgVoteV1) {
+				t.Errorf("BuildUnsignedVoteTx() vote = %v, metadata only expected in %s", msg, TypeMsgVoteV1)
+			}
+		})
+	}
+}
+
+func TestAccountSequence(t *testing.T) {
+	tests := []struct {
+		name          string
+		account       string
+		accountNumber string
+		sequence      string
+		ok            bool
+	}{
+		{"base account", `{"@type":"/cosmos.auth.v1beta1.BaseAccount","account_number":"12","sequence":"7"}`, "12", "7", true},
+		{"missing sequence", `{"@type":"/cosmos.auth.v1beta1.BaseAccount","account_number":"12"}`, "12", "0", true},
+		{"module account", `{"@type":"/cosmos.auth.v1beta1.ModuleAccount","base_account":{"account_number":"3","sequence":"0"},"name":"gov"}`, "3", "0", true},
+		{"continuous vesting account", `{"@type":"/cosmos.vesting.v1beta1.ContinuousVestingAccount","base_vesting_account":{"base_account":{"account_number":"45","sequence":"9"},"original_vesting":[]},"start_time":"0"}`, "45", "9", true},
+		{"vesting account missing sequence", `{"@type":"/cosmos.vesting.v1beta1.DelayedVestingAccount","base_vesting_account":{"base_account":{"account_number":"45"}}}`, "45", "0", true},
+		{"no account number", `{"@type":"/cosmos.auth.v1beta1.BaseAccount","sequence":"7"}`, "", "", false},
+		{"invalid account", `"cosmos1voter"`, "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accountNumber, sequence, ok := accountSequence(json.RawMessage(test.account))
+			if accountNumber != test.accountNumber || sequence != test.sequence || ok != test.ok {
+				t.Errorf("accountSequence() = %q, %q, %v, want %q, %q, %v", accountNumber, sequence, ok, test.accountNumber, test.sequence, test.ok)
+			}
+		})
+	}
+}
+
+func TestParseCoins(t *testing.T) {
+	tests := []struct {
+		value   string
+		coins   []Coin
+		wantErr bool
+	}{
+		{"5000uatom", []Coin{{Denom: "uatom", Amount: "5000"}}, false},
+		{"10uatom,20ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", []Coin{
+			{Denom: "uatom", Amount: "10"},
+			{Denom: "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", Amount: "20"},
+		}, false},
+		{"10uosmo, 5factory/osmo1abc/ustake", []Coin{
+			{Denom: "uosmo", Amount: "10"},
+			{Denom: "factory/osmo1abc/ustake", Amount: "5"},
+		}, false},
+		{"", nil, false},
+		{"uatom", nil, true},
+		{"5000", nil, true},
+		{"-5uatom", nil, true},
+		{"5 uatom", nil, true},
+		{"10uatom,ibc/ABC", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			coins, err := ParseCoins(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseCoins(%q) error = %v, wantErr %v", test.value, err, test.wantErr)
+			}
+			if !reflect.DeepEqual(coins, test.coins) {
+				t.Errorf("ParseCoins(%q) = %v, want %v", test.value, coins, test.coins)
+			}
+		})
+	}
+}
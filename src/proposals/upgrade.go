@@ -0,0 +1,155 @@
+package proposals
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// Message and legacy content types that schedule a software upgrade
+const (
+	TypeMsgSoftwareUpgrade       = "/cosmos.upgrade.v1beta1.MsgSoftwareUpgrade"
+	TypeSoftwareUpgradeProposal  = "/cosmos.upgrade.v1beta1.SoftwareUpgradeProposal"
+	TypeMsgExecLegacyContent     = "/cosmos.gov.v1.MsgExecLegacyContent"
+	TypeMsgExecLegacyContentBeta = "/cosmos.gov.v1beta1.MsgExecLegacyContent"
+)
+
+// UpgradePlan is the plan of a software upgrade proposal
+type UpgradePlan struct {
+	Name   string `json:"name"`
+	Height int64  `json:"height,string"`
+	Info   string `json:"info"`
+	// EstimatedTime is when the upgrade height is expected to be reached, refreshed on every run
+	EstimatedTime string `json:"estimated_time,omitempty"`
+	// ParsedInfo is the binary information read from Info, refreshed on every run
+	ParsedInfo *UpgradeInfo `json:"parsed_info,omitempty"`
+}
+
+func upgradePlan(contentType string, plan *UpgradePlan) *UpgradePlan {
+	if plan == nil || plan.Height == 0 {
+		return nil
+	}
+	if contentType != TypeMsgSoftwareUpgrade && contentType != TypeSoftwareUpgradeProposal {
+		return nil
+	}
+	upgrade := *plan
+	return &upgrade
+}
+
+// upgradePlanV1 finds a software upgrade among the messages of a v1 proposal, either as a
+// MsgSoftwareUpgrade or as legacy content
+func upgradePlanV1(messages []proposalMessage) *UpgradePlan {
+	for _, message := range messages {
+		if upgrade := upgradePlan(message.Type, message.Plan); upgrade != nil {
+			return upgrade
+		}
+		if message.Type == TypeMsgExecLegacyContent || message.Type == TypeMsgExecLegacyContentBeta {
+			if upgrade := upgradePlan(message.Content.Type, message.Content.Plan); upgrade != nil {
+				return upgrade
+			}
+		}
+	}
+	return nil
+}
+
+// HasPendingUpgrade reports whether the proposal schedules a software upgrade that may still happen
+func HasPendingUpgrade(proposal Proposal) bool {
+	return proposal.Upgrade != nil &&
+		(proposal.Status == ProposalStatusVotingPeriod || proposal.Status == ProposalStatusPassed)
+}
+
+// EstimateUpgradeTimes converts the upgrade height of passed and voting upgrade proposals into a
+// wall-clock estimate using the chain's recent average block time. Upgrades that already happened are
+// left without an estimate.
+func EstimateUpgradeTimes(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, propList []Proposal) error {
+	var latest *chainclient.Block
+	var averageBlockTime time.Duration
+	for i := range propList {
+		if !HasPendingUpgrade(propList[i]) {
+			continue
+		}
+
+		if latest == nil {
+			var err error
+			latest, averageBlockTime, err = client.AverageBlockTime(ctx, chain.APIEndpoint, BlockTimeWindow)
+			if err != nil {
+				return fmt.Errorf("error estimating block time: %v", err)
+			}
+		}
+
+		upgrade := propList[i].Upgrade
+		if upgrade.Height <= latest.Height {
+			continue
+		}
+		upgrade.EstimatedTime = chainclient.EstimateTimeAt(latest, averageBlockTime, upgrade.Height).UTC().Format(time.RFC3339)
+	}
+	return nil
+}
+
+// UpgradeVersionMatches reports whether a node running nodeVersion is on the version required by the
+// upgrade plan. The required version is taken from expectedVersion when given, and otherwise from the
+// plan name, accepting any patch release of it (a node on v15.0.1 matches an upgrade named "v15").
+// Plans whose info or binaries name the node version as a whole token also match, as upgrade names
+// aren't always versions.
+func UpgradeVersionMatches(plan UpgradePlan, nodeVersion string, expectedVersion string) bool {
+	node := normalizeVersion(nodeVersion)
+	if node == "" {
+		return false
+	}
+
+	if expectedVersion != "" {
+		return node == normalizeVersion(expectedVersion)
+	}
+
+	name := normalizeVersion(plan.Name)
+	if node == name || strings.HasPrefix(node, name+".") || strings.HasPrefix(node, name+"-") {
+		return true
+	}
+	texts := []string{plan.Info}
+	if plan.ParsedInfo != nil {
+		for _, binary := range plan.ParsedInfo.Binaries {
+			texts = append(texts, binary.URL)
+		}
+	}
+	for _, text := range texts {
+		if hasVersionToken(text, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasVersionToken reports whether the text names the normalized version, with or without a v prefix,
+// not as part of a longer version or word, so a node on v1 doesn't match a plan for v15 or v1.2
+func hasVersionToken(text string, version string) bool {
+	text = strings.ToLower(text)
+	for start := 0; start < len(text); {
+		i := strings.Index(text[start:], version)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(version)
+		before := i
+		if before > 0 && text[before-1] == 'v' {
+			before--
+		}
+		if (before == 0 || !isVersionChar(text[before-1])) && (end == len(text) || !isVersionChar(text[end])) {
+			return true
+		}
+		start = i + 1
+	}
+	return false
+}
+
+func isVersionChar(c byte) bool {
+	return c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z')
+}
+
+func normalizeVersion(version string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+}
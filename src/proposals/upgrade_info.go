@@ -0,0 +1,157 @@
+package proposals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"tendermint_proposal_monitor/chainclient"
+)
+
+// Platforms whose binaries are shown in upgrade alerts
+var UpgradeInfoPlatforms = []string{"linux/amd64", "linux/arm64"}
+
+// MaxPlanInfoBytes caps the size of plan info read from a URL
+const MaxPlanInfoBytes = 1 << 20
+
+// planInfoCache keeps the plan info read from each URL, which passed upgrades don't change, so it is
+// fetched once rather than on every run
+var planInfoCache sync.Map
+
+// UpgradeBinary is a downloadable binary listed in the upgrade plan info
+type UpgradeBinary struct {
+	URL      string `json:"url"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// UpgradeInfo is the parsed plan info, following the format used by Cosmovisor: a JSON object with
+// binaries per platform, given inline or at a URL, with the checksum as a query parameter of each URL
+type UpgradeInfo struct {
+	Binaries map[string]UpgradeBinary `json:"binaries,omitempty"`
+	// SourceURL is set when the info pointed to a URL the binaries were read from
+	SourceURL string `json:"source_url,omitempty"`
+	// Problems lists what is missing or malformed, so operators find out before the upgrade
+	Problems []string `json:"problems,omitempty"`
+}
+
+// ParseUpgradeInfos parses the plan info of every pending upgrade proposal. Plan info at a URL is only
+// fetched once the proposal passed, since anyone can submit a proposal pointing anywhere.
+func ParseUpgradeInfos(ctx context.Context, client *chainclient.Client, propList []Proposal) {
+	for i := range propList {
+		if !HasPendingUpgrade(propList[i]) {
+			continue
+		}
+		upgrade := propList[i].Upgrade
+		upgrade.ParsedInfo = ParseUpgradeInfo(ctx, client, upgrade.Info, propList[i].Status == ProposalStatusPassed)
+	}
+}
+
+// ParseUpgradeInfo parses plan info given as JSON or as a URL to JSON, fetching it from the URL only
+// when fetch is set; otherwise just the URL is recorded. Problems are recorded in the result rather
+// than returned as an error, since a plan without usable binary info is still valid.
+func ParseUpgradeInfo(ctx context.Context, client *chainclient.Client, info string, fetch bool) *UpgradeInfo {
+	parsed := &UpgradeInfo{}
+	info = strings.TrimSpace(info)
+	if info == "" {
+		parsed.Problems = append(parsed.Problems, "plan info is empty")
+		return parsed
+	}
+
+	raw := []byte(info)
+	if !strings.HasPrefix(info, "{") {
+		if !isHTTPURL(info) {
+			parsed.Problems = append(parsed.Problems, "plan info is neither JSON nor a URL")
+			return parsed
+		}
+		parsed.SourceURL = info
+		if !fetch {
+			return parsed
+		}
+
+		body, err := fetchPlanInfo(ctx, client, info)
+		if err != nil {
+			parsed.Problems = append(parsed.Problems, fmt.Sprintf("error fetching plan info: %v", err))
+			return parsed
+		}
+		raw = body
+	}
+
+	var content struct {
+		Binaries map[string]string `json:"binaries"`
+	}
+	err := json.Unmarshal(raw, &content)
+	if err != nil {
+		parsed.Problems = append(parsed.Problems, fmt.Sprintf("plan info is malformed: %v", err))
+		return parsed
+	}
+	if len(content.Binaries) == 0 {
+		parsed.Problems = append(parsed.Problems, "plan info lists no binaries")
+		return parsed
+	}
+
+	parsed.Binaries = make(map[string]UpgradeBinary)
+	for platform, binaryURL := range content.Binaries {
+		if !isHTTPURL(binaryURL) {
+			parsed.Problems = append(parsed.Problems, fmt.Sprintf("binary URL for %s is invalid", platform))
+			continue
+		}
+		parsed.Binaries[platform] = UpgradeBinary{URL: binaryURL, Checksum: binaryChecksum(binaryURL)}
+	}
+
+	for _, platform := range UpgradeInfoPlatforms {
+		binary, ok := parsed.Binary(platform)
+		if !ok {
+			parsed.Problems = append(parsed.Problems, fmt.Sprintf("no binary for %s", platform))
+			continue
+		}
+		if binary.Checksum == "" {
+			parsed.Problems = append(parsed.Problems, fmt.Sprintf("no checksum for the %s binary", platform))
+		}
+	}
+	return parsed
+}
+
+// fetchPlanInfo reads plan info from a URL, or from the cache when it was read before. Failed fetches
+// aren't cached, so they are tried again on the next run.
+func fetchPlanInfo(ctx context.Context, client *chainclient.Client, infoURL string) ([]byte, error) {
+	if body, ok := planInfoCache.Load(infoURL); ok {
+		return body.([]byte), nil
+	}
+
+	body, statusCode, err := client.GetExternal(ctx, infoURL, MaxPlanInfoBytes)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d %s", statusCode, http.StatusText(statusCode))
+	}
+	planInfoCache.Store(infoURL, body)
+	return body, nil
+}
+
+// Binary returns the binary for the platform, falling back to the platform independent "any" entry
+func (i *UpgradeInfo) Binary(platform string) (UpgradeBinary, bool) {
+	if binary, ok := i.Binaries[platform]; ok {
+		return binary, true
+	}
+	binary, ok := i.Binaries["any"]
+	return binary, ok
+}
+
+// binaryChecksum reads the checksum query parameter of a binary URL, e.g. ?checksum=sha256:abc...
+func binaryChecksum(binaryURL string) string {
+	parsed, err := url.Parse(binaryURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get("checksum")
+}
+
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,38 @@
+package proposals
+
+import "testing"
+
+func TestUpgradeVersionMatches(t *testing.T) {
+	binaries := &UpgradeInfo{Binaries: map[string]UpgradeBinary{
+		"linux/amd64": {URL: "https://github.com/cosmos/gaia/releases/download/v15.0.1/gaiad-v15.0.1-linux-amd64?checksum=sha256:1f0a"},
+	}}
+	tests := []struct {
+		name            string
+		plan            UpgradePlan
+		nodeVersion     string
+		expectedVersion string
+		matches         bool
+	}{
+		{"expected version", UpgradePlan{Name: "v15"}, "v15.0.1", "15.0.1", true},
+		{"other expected version", UpgradePlan{Name: "v15"}, "v15.0.0", "v15.0.1", false},
+		{"patch release of name", UpgradePlan{Name: "v15"}, "v15.0.1", "", true},
+		{"release candidate of name", UpgradePlan{Name: "v15"}, "v15-rc1", "", true},
+		{"longer version than name", UpgradePlan{Name: "v1"}, "v15.0.1", "", false},
+		{"older name", UpgradePlan{Name: "v15"}, "v14.1.0", "", false},
+		{"version in binary URL", UpgradePlan{Name: "Cheqd", ParsedInfo: binaries}, "15.0.1", "", true},
+		{"version in info", UpgradePlan{Name: "Cheqd", Info: `{"binaries":{"linux/amd64":"https://example.com/v2.1.0/node"}}`}, "v2.1.0", "", true},
+		{"short version within longer one", UpgradePlan{Name: "Cheqd", ParsedInfo: binaries}, "v1", "", false},
+		{"prefix of version", UpgradePlan{Name: "Cheqd", ParsedInfo: binaries}, "15.0", "", false},
+		{"digits of checksum", UpgradePlan{Name: "Cheqd", ParsedInfo: binaries}, "1", "", false},
+		{"no node version", UpgradePlan{Name: "v15"}, "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matches := UpgradeVersionMatches(test.plan, test.nodeVersion, test.expectedVersion)
+			if matches != test.matches {
+				t.Errorf("UpgradeVersionMatches(%q, %q, %q) = %v, want %v", test.plan.Name, test.nodeVersion, test.expectedVersion, matches, test.matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package proposals
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Vote is how a voter voted on a proposal. Weighted votes split the voting power over several
+// options; any other vote has a single option of weight 1.
+type Vote struct {
+	Voter    string           `firestore:"voter" json:"voter"`
+	Options  []WeightedOption `firestore:"options" json:"options"`
+	Metadata string           `firestore:"metadata" json:"metadata,omitempty"`
+}
+
+type WeightedOption struct {
+	Option string `firestore:"option" json:"option"`
+	Weight string `firestore:"weight" json:"weight"`
+}
+
+// voteOptionNames maps the vote options of x/gov and x/group, by name or number, and the options of
+// other governance implementations onto the names shown in alerts
+var voteOptionNames = map[string]string{
+	"VOTE_OPTION_YES":          "Yes",
+	"VOTE_OPTION_ABSTAIN":      "Abstain",
+	"VOTE_OPTION_NO":           "No",
+	"VOTE_OPTION_NO_WITH_VETO": "No with veto",
+	"1":                        "Yes",
+	"2":                        "Abstain",
+	"3":                        "No",
+	"4":                        "No with veto",
+	"yes":                      "Yes",
+	"abstain":                  "Abstain",
+	"no":                       "No",
+	"veto":                     "No with veto",
+	"yay":                      "Yes",
+	"nay":                      "No",
+}
+
+// OptionName returns the display name of a vote option
+func OptionName(option string) string {
+	if name, ok := voteOptionNames[option]; ok {
+		return name
+	}
+	return option
+}
+
+// String formats the vote as "Yes", or as weighted options such as "70% Yes / 30% Abstain"
+func (v *Vote) String() string {
+	if v == nil || len(v.Options) == 0 {
+		return "Unknown"
+	}
+	if len(v.Options) == 1 {
+		return OptionName(v.Options[0].Option)
+	}
+
+	var parts []string
+	for _, option := range v.Options {
+		weight, err := strconv.ParseFloat(option.Weight, 64)
+		if err != nil {
+			parts = append(parts, fmt.Sprintf("%s %s", option.Weight, OptionName(option.Option)))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s%% %s", strconv.FormatFloat(weight*100, 'f', -1, 64), OptionName(option.Option)))
+	}
+	return strings.Join(parts, " / ")
+}
+
+// newVote builds a vote from the options of a response, or from the single option that v1beta1
+// responses and messages still carry next to them
+func newVote(voter string, options []VoteOption, option string, metadata string) *Vote {
+	vote := &Vote{Voter: voter, Metadata: metadata}
+	for _, o := range options {
+		vote.Options = append(vote.Options, WeightedOption{Option: o.Option, Weight: o.Weight})
+	}
+	if len(vote.Options) == 0 && option != "" {
+		vote.Options = []WeightedOption{{Option: option, Weight: "1"}}
+	}
+	return vote
+}
+
+// SameOptions reports whether two votes split the voting power the same way, whichever way their
+// options are named or ordered
+func (v *Vote) SameOptions(other *Vote) bool {
+	if v == nil || other == nil {
+		return v == other
+	}
+	return v.normalizedOptions() == other.normalizedOptions()
+}
+
+// normalizedOptions writes the options by display name, sorted, with their weights as numbers
+func (v *Vote) normalizedOptions() string {
+	var parts []string
+	for _, option := range v.Options {
+		weight := option.Weight
+		if parsed, err := strconv.ParseFloat(option.Weight, 64); err == nil {
+			weight = strconv.FormatFloat(parsed, 'f', -1, 64)
+		}
+		parts = append(parts, OptionName(option.Option)+"="+weight)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,68 @@
+package proposals
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Vote lookups fail with one of these errors when a response says nothing about the vote, so the
+// vote status is unknown rather than not voted
+var (
+	// ErrVoteStatusTransient is returned for network errors, rate limits and server errors that
+	// persisted through the retries of the client
+	ErrVoteStatusTransient = errors.New("vote status temporarily unavailable")
+	// ErrVoteStatusUnsupported is returned when the endpoint doesn't serve vote lookups, or rejects
+	// the query, such as for an address of another chain
+	ErrVoteStatusUnsupported = errors.New("vote status not supported by the endpoint")
+	// ErrVoteUnconfirmed is returned when the votes endpoint doesn't know the vote and the
+	// transactions of the proposal couldn't be searched. Votes are only pruned from state once the
+	// voting period ends, so it means not voted for proposals still in their voting period.
+	ErrVoteUnconfirmed = fmt.Errorf("%w: vote not found and transactions not searchable", ErrVoteStatusTransient)
+)
+
+// gRPC status codes the gateway answers with for a missing vote
+const (
+	grpcInvalidArgument = 3
+	grpcNotFound        = 5
+)
+
+// missingVoteMessage is part of the error the gov module returns for a missing vote
+const missingVoteMessage = "not found for proposal"
+
+// classifyVoteResponse returns nil when a non-200 response of a votes endpoint means the vote doesn't
+// exist, and the reason the vote status is unknown otherwise. The gov module of Cosmos SDK 0.45 to
+// 0.50 reports a missing vote as an InvalidArgument, with a 400, saying the voter is "not found for
+// proposal". Other chains use the gRPC NotFound code, or wrap it in an internal error. A NotFound of
+// the gateway itself, for a route it doesn't serve, only says "Not Found".
+func classifyVoteResponse(proposalID string, statusCode int, body []byte) error {
+	var status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &status) == nil && status.Message != "" && !strings.EqualFold(status.Message, http.StatusText(http.StatusNotFound)) {
+		if status.Code == grpcNotFound || strings.Contains(status.Message, "code = NotFound") {
+			return nil
+		}
+		invalidArgument := status.Code == grpcInvalidArgument || strings.Contains(status.Message, "code = InvalidArgument")
+		if invalidArgument && strings.Contains(status.Message, missingVoteMessage) {
+			return nil
+		}
+	}
+
+	return voteStatusError(proposalID, statusCode, status.Message)
+}
+
+// voteStatusError classifies a response that says nothing about the vote by its status code
+func voteStatusError(proposalID string, statusCode int, message string) error {
+	kind := ErrVoteStatusUnsupported
+	if statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError {
+		kind = ErrVoteStatusTransient
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return fmt.Errorf("%w for proposal %s: %d %s", kind, proposalID, statusCode, message)
+}
@@ -0,0 +1,66 @@
+package proposals
+
+import "testing"
+
+func TestSameOptions(t *testing.T) {
+	yes := &Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_YES", Weight: "1.000000000000000000"}}}
+	tests := []struct {
+		name  string
+		vote  *Vote
+		other *Vote
+		same  bool
+	}{
+		{"same option", yes, &Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_YES", Weight: "1"}}}, true},
+		{"option by number", yes, &Vote{Options: []WeightedOption{{Option: "1", Weight: "1"}}}, true},
+		{"option of another implementation", yes, &Vote{Options: []WeightedOption{{Option: "yes", Weight: "1"}}}, true},
+		{"other option", yes, &Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_NO", Weight: "1"}}}, false},
+		{
+			"weights in another order",
+			&Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_YES", Weight: "0.7"}, {Option: "VOTE_OPTION_ABSTAIN", Weight: "0.3"}}},
+			&Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_ABSTAIN", Weight: "0.300000000000000000"}, {Option: "VOTE_OPTION_YES", Weight: "0.700000000000000000"}}},
+			true,
+		},
+		{
+			"other weights",
+			&Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_YES", Weight: "0.7"}, {Option: "VOTE_OPTION_ABSTAIN", Weight: "0.3"}}},
+			&Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_YES", Weight: "0.6"}, {Option: "VOTE_OPTION_ABSTAIN", Weight: "0.4"}}},
+			false,
+		},
+		{"weighted and single option", yes, &Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_YES", Weight: "0.5"}, {Option: "VOTE_OPTION_NO", Weight: "0.5"}}}, false},
+		{"unparsed weights", &Vote{Options: []WeightedOption{{Option: "yes", Weight: "all"}}}, &Vote{Options: []WeightedOption{{Option: "yes", Weight: "all"}}}, true},
+		{"nil votes", nil, nil, true},
+		{"nil and vote", nil, yes, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if same := test.vote.SameOptions(test.other); same != test.same {
+				t.Errorf("SameOptions = %v, want %v", same, test.same)
+			}
+			if same := test.other.SameOptions(test.vote); same != test.same {
+				t.Errorf("SameOptions of the other vote = %v, want %v", same, test.same)
+			}
+		})
+	}
+}
+
+func TestVoteString(t *testing.T) {
+	tests := []struct {
+		name string
+		vote *Vote
+		want string
+	}{
+		{"single option", &Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_NO_WITH_VETO", Weight: "1"}}}, "No with veto"},
+		{"weighted options", &Vote{Options: []WeightedOption{{Option: "VOTE_OPTION_YES", Weight: "0.700000000000000000"}, {Option: "VOTE_OPTION_ABSTAIN", Weight: "0.300000000000000000"}}}, "70% Yes / 30% Abstain"},
+		{"no options", &Vote{}, "Unknown"},
+		{"nil vote", nil, "Unknown"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.vote.String(); got != test.want {
+				t.Errorf("String = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package proposals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"tendermint_proposal_monitor/chainclient"
+	"tendermint_proposal_monitor/config"
+)
+
+// Message types of a vote, cast directly or through an authz grantee
+const (
+	TypeMsgVoteV1              = "/cosmos.gov.v1.MsgVote"
+	TypeMsgVoteV1Beta1         = "/cosmos.gov.v1beta1.MsgVote"
+	TypeMsgVoteWeightedV1      = "/cosmos.gov.v1.MsgVoteWeighted"
+	TypeMsgVoteWeightedV1Beta1 = "/cosmos.gov.v1beta1.MsgVoteWeighted"
+	TypeMsgExec                = "/cosmos.authz.v1beta1.MsgExec"
+)
+
+// VoteTx is a successful transaction casting a vote
+type VoteTx struct {
+	TxHash    string `json:"txhash"`
+	Height    string `json:"height"`
+	Timestamp string `json:"timestamp"`
+	// Grantee is set when the vote was cast through authz
+	Grantee string `json:"grantee,omitempty"`
+	Vote    *Vote  `json:"vote"`
+}
+
+// voteMsg covers the vote messages and MsgExec, whose msgs hold the messages executed for the granter
+type voteMsg struct {
+	Type       string       `json:"@type"`
+	ProposalID string       `json:"proposal_id"`
+	Voter      string       `json:"voter"`
+	Option     string       `json:"option"`
+	Options    []VoteOption `json:"options"`
+	Metadata   string       `json:"metadata"`
+	Grantee    string       `json:"grantee"`
+	Msgs       []voteMsg    `json:"msgs"`
+}
+
+type txSearchResponse struct {
+	Txs []struct {
+		Body struct {
+			Messages []voteMsg `json:"messages"`
+		} `json:"body"`
+	} `json:"txs"`
+	TxResponses []struct {
+		Height    string `json:"height"`
+		TxHash    string `json:"txhash"`
+		Code      int    `json:"code"`
+		Timestamp string `json:"timestamp"`
+	} `json:"tx_responses"`
+	// Total is the number of transactions found, given at the top level from SDK 0.47 and in the
+	// pagination before
+	Total      string `json:"total"`
+	Pagination *struct {
+		Total string `json:"total"`
+	} `json:"pagination"`
+}
+
+// total returns the number of transactions found, or -1 when the response doesn't say
+func (r *txSearchResponse) total() int {
+	total := r.Total
+	if total == "" && r.Pagination != nil {
+		total = r.Pagination.Total
+	}
+	n, err := strconv.Atoi(total)
+	if err != nil || (n == 0 && len(r.TxResponses) > 0) {
+		return -1
+	}
+	return n
+}
+
+// FindVoteTx searches the transactions of a proposal for a MsgVote, or a MsgExec executing one, cast
+// for the voter. It is the fallback for when the votes endpoint doesn't know the vote: votes are
+// pruned from state once the voting period ends, and votes cast through authz aren't attributed to
+// the granter on every chain. Transactions are searched newest first, so the latest vote is found
+// when the vote was changed. Nil is returned when no such transaction is found.
+func FindVoteTx(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, proposalID string, voter string) (*VoteTx, error) {
+	// The voter attribute of proposal_vote events is set from SDK 0.47. Before, the gov module emits a
+	// message event with the voter as sender. Both name the granter of votes cast through authz.
+	for _, senderEvent := range []string{"proposal_vote.voter", "message.sender"} {
+		conditions := []string{
+			fmt.Sprintf("proposal_vote.proposal_id='%s'", proposalID),
+			fmt.Sprintf("%s='%s'", senderEvent, voter),
+		}
+		resp, err := searchTxs(ctx, client, chain, conditions, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		if tx := voteTxOf(resp, proposalID, voter); tx != nil {
+			return tx, nil
+		}
+	}
+	return nil, nil
+}
+
+// ProposalTxsIndexed reports whether the node indexed the transactions of the proposal from its
+// submission on, so a vote missing from a search of them wasn't cast. Nodes prune old transactions from
+// their index, which makes searches come back empty rather than fail.
+func ProposalTxsIndexed(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, proposalID string) (bool, error) {
+	resp, err := searchTxs(ctx, client, chain, []string{fmt.Sprintf("submit_proposal.proposal_id='%s'", proposalID)}, 0, 0)
+	if err != nil {
+		return false, err
+	}
+	return len(resp.TxResponses) > 0, nil
+}
+
+// The vote transactions of a voter are searched in pages, up to a bound
+const (
+	voteTxsPageLimit = 50
+	maxVoteTxsPages  = 10
+)
+
+// VoterVoteTxs is the latest vote transaction of a voter on every proposal voted on, by proposal ID.
+// Complete is set when every transaction since the cutoff of the search was seen, so a proposal
+// missing from Txs wasn't voted on since then.
+type VoterVoteTxs struct {
+	Txs      map[string]*VoteTx
+	Complete bool
+}
+
+// FindVoterVoteTxs searches the transactions of votes cast for the voter on any proposal, so the votes
+// of every open proposal are looked up with a single search. Pages are read newest first until the
+// transactions run out or go back before since, such as the start of the oldest open voting period.
+func FindVoterVoteTxs(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, voter string, since time.Time) (*VoterVoteTxs, error) {
+	// From SDK 0.47, the gov module sets the voter attribute of proposal_vote events to the voter the
+	// vote counts for, the granter of votes cast through authz. Nodes without the attribute find
+	// nothing rather than fail, so an empty search is only trusted from nodes known to set it.
+	result, sdk047, err := searchVoterVoteTxs(ctx, client, chain, []string{fmt.Sprintf("proposal_vote.voter='%s'", voter)}, voter, since)
+	if err != nil || len(result.Txs) > 0 || sdk047 {
+		return result, err
+	}
+
+	// Before SDK 0.47, the gov module emits a message event of its own with the voter as sender, for
+	// votes cast through authz as well
+	result, _, err = searchVoterVoteTxs(ctx, client, chain, []string{fmt.Sprintf("message.sender='%s'", voter), "message.module='governance'"}, voter, since)
+	return result, err
+}
+
+// searchVoterVoteTxs reads the pages of a search for the vote transactions of the voter, and reports
+// whether the node is from SDK 0.47, which gives the total of a search at the top level
+func searchVoterVoteTxs(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, conditions []string, voter string, since time.Time) (*VoterVoteTxs, bool, error) {
+	result := &VoterVoteTxs{Txs: make(map[string]*VoteTx)}
+	sdk047 := false
+	for page := 1; page <= maxVoteTxsPages && !result.Complete; page++ {
+		resp, err := searchTxs(ctx, client, chain, conditions, page, voteTxsPageLimit)
+		if err != nil {
+			return nil, false, err
+		}
+		addVoteTxs(result.Txs, resp, voter)
+		sdk047 = sdk047 || resp.Total != ""
+
+		total := resp.total()
+		result.Complete = len(resp.TxResponses) < voteTxsPageLimit || (total >= 0 && page*voteTxsPageLimit >= total) || searchedBefore(resp, since)
+	}
+	return result, sdk047, nil
+}
+
+// searchedBefore reports whether the page of transactions, newest first, goes back before the time
+func searchedBefore(resp *txSearchResponse, since time.Time) bool {
+	if len(resp.TxResponses) == 0 {
+		return true
+	}
+	oldest, err := time.Parse(time.RFC3339, resp.TxResponses[len(resp.TxResponses)-1].Timestamp)
+	return err == nil && oldest.Before(since)
+}
+
+// addVoteTxs adds the vote transactions of the page to those of earlier pages, which are newer
+func addVoteTxs(voteTxs map[string]*VoteTx, resp *txSearchResponse, voter string) {
+	for i, tx := range resp.Txs {
+		if i >= len(resp.TxResponses) || resp.TxResponses[i].Code != 0 {
+			continue
+		}
+		for _, msg := range tx.Body.Messages {
+			for _, proposalID := range votedProposals(msg, voter) {
+				// Transactions are newest first, so the first vote found is the current one
+				if _, found := voteTxs[proposalID]; found {
+					continue
+				}
+				vote, grantee, _ := findVoteMsg(msg, proposalID, voter)
+				txResponse := resp.TxResponses[i]
+				voteTxs[proposalID] = &VoteTx{TxHash: txResponse.TxHash, Height: txResponse.Height, Timestamp: txResponse.Timestamp, Grantee: grantee, Vote: vote}
+			}
+		}
+	}
+}
+
+// votedProposals returns the proposals the message votes on for the voter
+func votedProposals(msg voteMsg, voter string) []string {
+	switch msg.Type {
+	case TypeMsgVoteV1, TypeMsgVoteV1Beta1, TypeMsgVoteWeightedV1, TypeMsgVoteWeightedV1Beta1:
+		if msg.Voter == voter {
+			return []string{msg.ProposalID}
+		}
+	case TypeMsgExec:
+		var proposalIDs []string
+		for _, inner := range msg.Msgs {
+			proposalIDs = append(proposalIDs, votedProposals(inner, voter)...)
+		}
+		return proposalIDs
+	}
+	return nil
+}
+
+// searchTxs queries transactions by events, using the query parameter of SDK 0.50 and falling back
+// to the events parameter it replaced. A page of 0 leaves paging to the node's defaults.
+func searchTxs(ctx context.Context, client *chainclient.Client, chain config.ChainConfig, conditions []string, page int, limit int) (*txSearchResponse, error) {
+	query := conditions[0]
+	for _, condition := range conditions[1:] {
+		query += " AND " + condition
+	}
+
+	params := url.Values{"query": {query}, "order_by": {"ORDER_BY_DESC"}}
+	if page > 0 {
+		params.Set("page", strconv.Itoa(page))
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	var resp txSearchResponse
+	err := client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/tx/v1beta1/txs?%s", chain.APIEndpoint, params.Encode()), &resp)
+	var statusErr *chainclient.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest {
+		// Before SDK 0.46, pages are only given as an offset
+		params = url.Values{"events": conditions, "order_by": {"ORDER_BY_DESC"}}
+		if page > 0 {
+			params.Set("pagination.offset", strconv.Itoa((page-1)*limit))
+			params.Set("pagination.limit", strconv.Itoa(limit))
+		}
+		err = client.GetJSON(ctx, fmt.Sprintf("%s/cosmos/tx/v1beta1/txs?%s", chain.APIEndpoint, params.Encode()), &resp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error searching transactions: %v", err)
+	}
+	return &resp, nil
+}
+
+func voteTxOf(resp *txSearchResponse, proposalID string, voter string) *VoteTx {
+	for i, tx := range resp.Txs {
+		if i >= len(resp.TxResponses) || resp.TxResponses[i].Code != 0 {
+			continue
+		}
+		for _, msg := range tx.Body.Messages {
+			vote, grantee, ok := findVoteMsg(msg, proposalID, voter)
+			if !ok {
+				continue
+			}
+			txResponse := resp.TxResponses[i]
+			return &VoteTx{TxHash: txResponse.TxHash, Height: txResponse.Height, Timestamp: txResponse.Timestamp, Grantee: grantee, Vote: vote}
+		}
+	}
+	return nil
+}
+
+// findVoteMsg returns the vote the message casts on the proposal for the voter, along with the
+// grantee when it does so through MsgExec
+func findVoteMsg(msg voteMsg, proposalID string, voter string) (*Vote, string, bool) {
+	switch msg.Type {
+	case TypeMsgVoteV1, TypeMsgVoteV1Beta1, TypeMsgVoteWeightedV1, TypeMsgVoteWeightedV1Beta1:
+		if msg.ProposalID == proposalID && msg.Voter == voter {
+			return newVote(voter, msg.Options, msg.Option, msg.Metadata), "", true
+		}
+	case TypeMsgExec:
+		for _, inner := range msg.Msgs {
+			if vote, _, ok := findVoteMsg(inner, proposalID, voter); ok {
+				return vote, msg.Grantee, true
+			}
+		}
+	}
+	return nil, "", false
+}
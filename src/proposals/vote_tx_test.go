@@ -0,0 +1,200 @@
+package proposals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testVoter = "cosmos1voter"
+
+// voteTxsPage is a page of a transaction search, with a vote of the voter on proposals firstID,
+// firstID-1 and so on, an hour apart going back from newest
+func voteTxsPage(count int, firstID int, newest time.Time, total string) string {
+	type msg map[string]interface{}
+	var txs, txResponses []interface{}
+	for i := 0; i < count; i++ {
+		proposalID := strconv.Itoa(firstID - i)
+		txs = append(txs, map[string]interface{}{"body": map[string]interface{}{"messages": []msg{{
+			"@type": TypeMsgVoteV1Beta1, "proposal_id": proposalID, "voter": testVoter, "option": "VOTE_OPTION_YES",
+		}}}})
+		txResponses = append(txResponses, map[string]interface{}{
+			"height": strconv.Itoa(1000 - i), "txhash": "HASH" + proposalID, "code": 0,
+			"timestamp": newest.Add(-time.Duration(i) * time.Hour).Format(time.RFC3339),
+		})
+	}
+	resp := map[string]interface{}{"txs": txs, "tx_responses": txResponses}
+	if total != "" {
+		resp["total"] = total
+	}
+	body, _ := json.Marshal(resp)
+	return string(body)
+}
+
+// serveSearches answers the pages of every search query in turn, with an empty page of a node from
+// SDK 0.47 past the last one
+func serveSearches(t *testing.T, pages map[string][]string, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		query := r.URL.Query()
+		page, err := strconv.Atoi(query.Get("page"))
+		if err != nil {
+			t.Errorf("search without page: %s", r.URL)
+		}
+		searchPages := pages[query.Get("query")]
+		if page < 1 || page > len(searchPages) {
+			w.Write([]byte(`{"txs":[],"tx_responses":[],"total":"0"}`))
+			return
+		}
+		w.Write([]byte(searchPages[page-1]))
+	}
+}
+
+// olderNodeEmptyPage is an empty search of a node before SDK 0.47, which gives the total in the pagination
+const olderNodeEmptyPage = `{"txs":[],"tx_responses":[],"pagination":{"next_key":null,"total":"0"}}`
+
+func TestFindVoterVoteTxs(t *testing.T) {
+	voterQuery := fmt.Sprintf("proposal_vote.voter='%s'", testVoter)
+	senderQuery := fmt.Sprintf("message.sender='%s' AND message.module='governance'", testVoter)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-14 * 24 * time.Hour)
+	// Full pages going back less than since
+	recentPage := func(page int, total string) string {
+		return voteTxsPage(voteTxsPageLimit, 1000-page*voteTxsPageLimit, now.Add(-time.Duration(page*voteTxsPageLimit)*time.Hour), total)
+	}
+
+	tests := []struct {
+		name     string
+		pages    map[string][]string
+		since    time.Time
+		txs      int
+		complete bool
+		requests int
+	}{
+		{
+			name:     "short page",
+			pages:    map[string][]string{voterQuery: {voteTxsPage(3, 100, now, "3")}},
+			txs:      3,
+			complete: true,
+			requests: 1,
+		},
+		{
+			name:     "total reached",
+			pages:    map[string][]string{voterQuery: {recentPage(0, "100"), recentPage(1, "100")}},
+			txs:      100,
+			complete: true,
+			requests: 2,
+		},
+		{
+			name:     "before since",
+			pages:    map[string][]string{voterQuery: {recentPage(0, "500"), voteTxsPage(voteTxsPageLimit, 900, since.Add(time.Hour), "500")}},
+			txs:      100,
+			complete: true,
+			requests: 2,
+		},
+		{
+			name:     "without total",
+			pages:    map[string][]string{voterQuery: {recentPage(0, ""), recentPage(1, ""), voteTxsPage(10, 900, now.Add(-100*time.Hour), "")}},
+			txs:      110,
+			complete: true,
+			requests: 3,
+		},
+		{
+			// A total of 0 next to transactions can't be trusted, so paging goes on to the empty page
+			name:     "inconsistent total",
+			pages:    map[string][]string{voterQuery: {recentPage(0, "0")}},
+			txs:      50,
+			complete: true,
+			requests: 2,
+		},
+		{
+			name: "page bound",
+			pages: map[string][]string{voterQuery: {
+				recentPage(0, ""), recentPage(1, ""), recentPage(2, ""), recentPage(3, ""), recentPage(4, ""),
+				recentPage(5, ""), recentPage(6, ""), recentPage(7, ""), recentPage(8, ""), recentPage(9, ""), recentPage(10, ""),
+			}},
+			since:    now.Add(-60 * 24 * time.Hour),
+			txs:      maxVoteTxsPages * voteTxsPageLimit,
+			complete: false,
+			requests: maxVoteTxsPages,
+		},
+		{
+			name:     "sender fallback",
+			pages:    map[string][]string{voterQuery: {olderNodeEmptyPage}, senderQuery: {voteTxsPage(2, 100, now, "2")}},
+			txs:      2,
+			complete: true,
+			requests: 2,
+		},
+		{
+			name:     "no votes",
+			pages:    map[string][]string{},
+			txs:      0,
+			complete: true,
+			requests: 1,
+		},
+		{
+			name:     "no votes on an older node",
+			pages:    map[string][]string{voterQuery: {olderNodeEmptyPage}, senderQuery: {olderNodeEmptyPage}},
+			txs:      0,
+			complete: true,
+			requests: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			client, chain := newTestChain(t, serveSearches(t, test.pages, &requests))
+
+			searchSince := since
+			if !test.since.IsZero() {
+				searchSince = test.since
+			}
+			voteTxs, err := FindVoterVoteTxs(context.Background(), client, chain, testVoter, searchSince)
+			if err != nil {
+				t.Fatalf("FindVoterVoteTxs failed: %v", err)
+			}
+			if len(voteTxs.Txs) != test.txs {
+				t.Errorf("found %d vote transactions, want %d", len(voteTxs.Txs), test.txs)
+			}
+			if voteTxs.Complete != test.complete {
+				t.Errorf("Complete = %v, want %v", voteTxs.Complete, test.complete)
+			}
+			if requests != test.requests {
+				t.Errorf("searched %d pages, want %d", requests, test.requests)
+			}
+		})
+	}
+}
+
+func TestFindVoterVoteTxsThroughAuthz(t *testing.T) {
+	page := `{"txs":[{"body":{"messages":[{"@type":"/cosmos.authz.v1beta1.MsgExec","grantee":"cosmos1grantee","msgs":[
+		{"@type":"/cosmos.gov.v1.MsgVoteWeighted","proposal_id":"42","voter":"cosmos1voter","options":[{"option":"VOTE_OPTION_YES","weight":"0.700000000000000000"},{"option":"VOTE_OPTION_ABSTAIN","weight":"0.300000000000000000"}]}]}]}},
+		{"body":{"messages":[{"@type":"/cosmos.gov.v1.MsgVote","proposal_id":"42","voter":"cosmos1voter","option":"VOTE_OPTION_NO"}]}}],
+		"tx_responses":[{"height":"200","txhash":"NEWER","code":0,"timestamp":"2026-10-19T10:00:00Z"},{"height":"100","txhash":"OLDER","code":0,"timestamp":"2026-10-18T10:00:00Z"}],
+		"total":"2"}`
+	requests := 0
+	client, chain := newTestChain(t, serveSearches(t, map[string][]string{fmt.Sprintf("proposal_vote.voter='%s'", testVoter): {page}}, &requests))
+
+	voteTxs, err := FindVoterVoteTxs(context.Background(), client, chain, testVoter, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("FindVoterVoteTxs failed: %v", err)
+	}
+	tx := voteTxs.Txs["42"]
+	if tx == nil {
+		t.Fatalf("no vote transaction found for proposal 42")
+	}
+	if tx.TxHash != "NEWER" || tx.Grantee != "cosmos1grantee" {
+		t.Errorf("vote transaction = %s by %q, want the latest one, NEWER by cosmos1grantee", tx.TxHash, tx.Grantee)
+	}
+	if got := tx.Vote.String(); got != "70% Yes / 30% Abstain" {
+		t.Errorf("vote = %q, want 70%% Yes / 30%% Abstain", got)
+	}
+	if !voteTxs.Complete {
+		t.Errorf("Complete = false, want true")
+	}
+}
@@ -1,18 +1,27 @@
 package services
 
 import (
+	"net/http"
+
+	"tendermint_proposal_monitor/chainclient"
 	"tendermint_proposal_monitor/config"
 	"tendermint_proposal_monitor/proposals"
 )
 
 type NewServices struct {
-	FirestoreHandler *proposals.FirestoreHandler
-	Configurations   *config.Configurations
+	Store          proposals.Store
+	ChainClient    *chainclient.Client
+	GovParams      *proposals.GovParamsCache
+	Configurations *config.Configurations
+	// AlertHTTPClient posts the Discord alerts, the default client when nil
+	AlertHTTPClient *http.Client
 }
 
-func New(firestore *proposals.FirestoreHandler, configs *config.Configurations) *NewServices {
+func New(store proposals.Store, chainClient *chainclient.Client, govParams *proposals.GovParamsCache, configs *config.Configurations) *NewServices {
 	return &NewServices{
-		FirestoreHandler: firestore,
-		Configurations:   configs,
+		Store:          store,
+		ChainClient:    chainClient,
+		GovParams:      govParams,
+		Configurations: configs,
 	}
 }
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test vectors of BIP-173, https://github.com/bitcoin/bips/blob/master/bip-0173.mediawiki#test-vectors
+func TestDecodeBech32(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		valid   bool
+	}{
+		{"uppercase", "A12UEL5L", true},
+		{"lowercase", "a12uel5l", true},
+		{"83 character hrp", "an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs", true},
+		{"every data character", "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", true},
+		{"hrp of separator", "11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j", true},
+		{"words", "split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", true},
+		{"question mark hrp", "?1ezyfcl", true},
+		{"hrp character 0x20", "\x201nwldj5", false},
+		{"hrp character 0x7f", "\x7f1axkwrx", false},
+		{"hrp character 0x80", "\x801eym55h", false},
+		{"no separator", "pzry9x0s0muk", false},
+		{"empty hrp", "1pzry9x0s0muk", false},
+		{"invalid data character", "x1b4n0q5v", false},
+		{"checksum too short", "li1dgmt3", false},
+		{"invalid checksum character", "de1lg7wt\xff", false},
+		{"checksum of uppercase hrp", "A1G7SGD8", false},
+		{"empty hrp without data", "10a06t8", false},
+		{"empty hrp with data", "1qzzfhee", false},
+		{"mixed case", "A12uEL5L", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hrp, data, err := DecodeBech32(test.address)
+			if !test.valid {
+				if err == nil {
+					t.Fatalf("DecodeBech32(%q) succeeded, want an error", test.address)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeBech32(%q) failed: %v", test.address, err)
+			}
+
+			encoded, err := EncodeBech32(hrp, data)
+			if err != nil {
+				t.Fatalf("EncodeBech32(%q) failed: %v", hrp, err)
+			}
+			if encoded != strings.ToLower(test.address) {
+				t.Errorf("EncodeBech32(%q) = %q, want %q", hrp, encoded, strings.ToLower(test.address))
+			}
+		})
+	}
+}
+
+func TestEncodeBech32AccountOfValidator(t *testing.T) {
+	_, data, err := DecodeBech32("cosmosvaloper1sjllsnramtg3ewxqwwrwjxfgc4n4ef9u2lcnj0")
+	if err != nil {
+		t.Fatalf("DecodeBech32 failed: %v", err)
+	}
+	account, err := EncodeBech32("cosmos", data)
+	if err != nil {
+		t.Fatalf("EncodeBech32 failed: %v", err)
+	}
+	if want := "cosmos1sjllsnramtg3ewxqwwrwjxfgc4n4ef9u0tvx7u"; account != want {
+		t.Errorf("account = %q, want %q", account, want)
+	}
+}
@@ -72,3 +72,15 @@ func NestedSliceToMap(kvs []OuterKV) map[string]map[string]bool {
 	}
 	return m
 }
+
+// FormatCountdown formats the time left from now until the end time, which is taken from the caller
+// so replayed runs count down from the time of their recording
+func FormatCountdown(endTime time.Time, now time.Time) string {
+	duration := endTime.Sub(now)
+	if duration < 0 {
+		duration = 0
+	}
+	hours := int(duration.Hours())
+	minutes := int(duration.Minutes()) % 60
+	return fmt.Sprintf("%d hours %02d minutes", hours, minutes)
+}